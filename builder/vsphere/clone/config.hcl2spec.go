@@ -30,10 +30,20 @@ type FlatConfig struct {
 	CDContent                       map[string]string                           `mapstructure:"cd_content" cty:"cd_content" hcl:"cd_content"`
 	CDLabel                         *string                                     `mapstructure:"cd_label" cty:"cd_label" hcl:"cd_label"`
 	VCenterServer                   *string                                     `mapstructure:"vcenter_server" cty:"vcenter_server" hcl:"vcenter_server"`
+	Port                            *int                                        `mapstructure:"port" cty:"port" hcl:"port"`
 	Username                        *string                                     `mapstructure:"username" cty:"username" hcl:"username"`
 	Password                        *string                                     `mapstructure:"password" cty:"password" hcl:"password"`
+	SessionToken                    *string                                     `mapstructure:"session_token" cty:"session_token" hcl:"session_token"`
 	InsecureConnection              *bool                                       `mapstructure:"insecure_connection" cty:"insecure_connection" hcl:"insecure_connection"`
 	Datacenter                      *string                                     `mapstructure:"datacenter" cty:"datacenter" hcl:"datacenter"`
+	UserAgent                       *string                                     `mapstructure:"user_agent" cty:"user_agent" hcl:"user_agent"`
+	Thumbprint                      *string                                     `mapstructure:"thumbprint" cty:"thumbprint" hcl:"thumbprint"`
+	KeepAliveInterval               *string                                     `mapstructure:"keep_alive_interval" cty:"keep_alive_interval" hcl:"keep_alive_interval"`
+	ConnectRetries                  *int                                        `mapstructure:"connect_retries" cty:"connect_retries" hcl:"connect_retries"`
+	Proxy                           *string                                     `mapstructure:"proxy" cty:"proxy" hcl:"proxy"`
+	CACertFile                      *string                                     `mapstructure:"ca_cert_file" cty:"ca_cert_file" hcl:"ca_cert_file"`
+	Debug                           *bool                                       `mapstructure:"debug" cty:"debug" hcl:"debug"`
+	ContentLibraryTimeout           *string                                     `mapstructure:"content_library_timeout" cty:"content_library_timeout" hcl:"content_library_timeout"`
 	Template                        *string                                     `mapstructure:"template" cty:"template" hcl:"template"`
 	DiskSize                        *int64                                      `mapstructure:"disk_size" cty:"disk_size" hcl:"disk_size"`
 	LinkedClone                     *bool                                       `mapstructure:"linked_clone" cty:"linked_clone" hcl:"linked_clone"`
@@ -183,10 +193,20 @@ func (*FlatConfig) HCL2Spec() map[string]hcldec.Spec {
 		"cd_content":                     &hcldec.AttrSpec{Name: "cd_content", Type: cty.Map(cty.String), Required: false},
 		"cd_label":                       &hcldec.AttrSpec{Name: "cd_label", Type: cty.String, Required: false},
 		"vcenter_server":                 &hcldec.AttrSpec{Name: "vcenter_server", Type: cty.String, Required: false},
+		"port":                           &hcldec.AttrSpec{Name: "port", Type: cty.Number, Required: false},
 		"username":                       &hcldec.AttrSpec{Name: "username", Type: cty.String, Required: false},
 		"password":                       &hcldec.AttrSpec{Name: "password", Type: cty.String, Required: false},
+		"session_token":                  &hcldec.AttrSpec{Name: "session_token", Type: cty.String, Required: false},
 		"insecure_connection":            &hcldec.AttrSpec{Name: "insecure_connection", Type: cty.Bool, Required: false},
 		"datacenter":                     &hcldec.AttrSpec{Name: "datacenter", Type: cty.String, Required: false},
+		"user_agent":                     &hcldec.AttrSpec{Name: "user_agent", Type: cty.String, Required: false},
+		"thumbprint":                     &hcldec.AttrSpec{Name: "thumbprint", Type: cty.String, Required: false},
+		"keep_alive_interval":            &hcldec.AttrSpec{Name: "keep_alive_interval", Type: cty.String, Required: false},
+		"connect_retries":                &hcldec.AttrSpec{Name: "connect_retries", Type: cty.Number, Required: false},
+		"proxy":                          &hcldec.AttrSpec{Name: "proxy", Type: cty.String, Required: false},
+		"ca_cert_file":                   &hcldec.AttrSpec{Name: "ca_cert_file", Type: cty.String, Required: false},
+		"debug":                          &hcldec.AttrSpec{Name: "debug", Type: cty.Bool, Required: false},
+		"content_library_timeout":        &hcldec.AttrSpec{Name: "content_library_timeout", Type: cty.String, Required: false},
 		"template":                       &hcldec.AttrSpec{Name: "template", Type: cty.String, Required: false},
 		"disk_size":                      &hcldec.AttrSpec{Name: "disk_size", Type: cty.Number, Required: false},
 		"linked_clone":                   &hcldec.AttrSpec{Name: "linked_clone", Type: cty.Bool, Required: false},
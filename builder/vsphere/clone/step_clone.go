@@ -142,6 +142,14 @@ func (s *StepCloneVM) Run(ctx context.Context, state multistep.StateBag) multist
 		return multistep.ActionHalt
 	}
 
+	// Fail before an existing virtual machine is removed if the virtual
+	// machine cannot be placed.
+	err = d.ValidatePlacement(s.Location.Cluster, s.Location.Host, s.Location.ResourcePool, s.Location.Folder)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error validating virtual machine placement: %s", err))
+		return multistep.ActionHalt
+	}
+
 	err = d.PreCleanVM(ui, vmPath, s.Force, s.Location.Cluster, s.Location.Host, s.Location.ResourcePool)
 	if err != nil {
 		state.Put("error", err)
@@ -164,6 +164,10 @@ func TestStepCreateVM_Run(t *testing.T) {
 		t.Fatalf("unexpected result: expected '%s' to be called", "FindVM")
 	}
 
+	if !driverMock.ValidatePlacementCalled {
+		t.Fatalf("unexpected result: expected '%s' to be called", "ValidatePlacement")
+	}
+
 	// Pre clean VM
 	if !driverMock.PreCleanVMCalled {
 		t.Fatalf("unexpected result: expected '%s' to be called", "PreCleanVM")
@@ -191,6 +195,41 @@ func TestStepCreateVM_Run(t *testing.T) {
 	}
 }
 
+func TestStepCloneVM_RunInvalidPlacement(t *testing.T) {
+	state := new(multistep.BasicStateBag)
+	state.Put("ui", &packersdk.BasicUi{
+		Reader: new(bytes.Buffer),
+		Writer: new(bytes.Buffer),
+	})
+	driverMock := driver.NewDriverMock()
+	driverMock.ValidatePlacementShouldFail = true
+	state.Put("driver", driverMock)
+	step := basicStepCloneVM()
+	step.Force = true
+	vmMock := new(driver.VirtualMachineMock)
+	driverMock.VM = vmMock
+
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionHalt, action)
+	}
+	if !driverMock.ValidatePlacementCalled {
+		t.Fatalf("unexpected result: expected '%s' to be called", "ValidatePlacement")
+	}
+
+	// The existing virtual machine is not removed and the clone is not
+	// started.
+	if driverMock.PreCleanVMCalled {
+		t.Fatalf("unexpected result: expected '%s' not to be called", "PreCleanVM")
+	}
+	if vmMock.CloneCalled {
+		t.Fatalf("unexpected result: expected '%s' not to be called", "Clone")
+	}
+	expected := "error validating virtual machine placement: resource pool 'test-resource-pool' not found"
+	if err := state.Get("error").(error); err.Error() != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, err)
+	}
+}
+
 func basicStepCloneVM() *StepCloneVM {
 	step := &StepCloneVM{
 		Config:   createConfig(),
@@ -9,11 +9,20 @@ import (
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
 )
 
+// startCleanup detaches the driver operations of the step cleanups from the
+// cancellation of the build.
+func startCleanup(state multistep.StateBag) {
+	if d, ok := state.Get("driver").(driver.Driver); ok {
+		d.StartCleanup()
+	}
+}
+
 func CleanupVM(state multistep.StateBag) {
 	st := state.Get("vm")
 	if st == nil {
 		return
 	}
+	startCleanup(state)
 	vm := st.(driver.VirtualMachine)
 
 	if vmDriver, ok := vm.(*driver.VirtualMachineDriver); ok {
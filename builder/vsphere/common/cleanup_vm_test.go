@@ -52,7 +52,9 @@ func Test_CleanupVM(t *testing.T) {
 	}
 	for _, tc := range testCases {
 		mockVM := &driver.VirtualMachineMock{}
+		d := driver.NewDriverMock()
 		state := cleanupTestState(mockVM)
+		state.Put("driver", d)
 		for k, v := range tc.ExtraState {
 			state.Put(k, v)
 		}
@@ -60,6 +62,9 @@ func Test_CleanupVM(t *testing.T) {
 		if mockVM.DestroyCalled != tc.ExpectDestroy {
 			t.Fatalf("unexpected result: expected '%s' to be called", "Destroy")
 		}
+		if !d.StartCleanupCalled {
+			t.Fatalf("unexpected result: expected '%s' to be called", "StartCleanup")
+		}
 	}
 
 }
@@ -317,6 +317,7 @@ func TestStepAddFloppy_Cleanup(t *testing.T) {
 			},
 			driverMock: new(driver.DriverMock),
 			expectedDriverMock: &driver.DriverMock{
+				StartCleanupCalled:  true,
 				FindDatastoreCalled: true,
 				FindDatastoreName:   "datastore",
 				FindDatastoreHost:   "host",
@@ -340,6 +341,7 @@ func TestStepAddFloppy_Cleanup(t *testing.T) {
 			},
 			driverMock: new(driver.DriverMock),
 			expectedDriverMock: &driver.DriverMock{
+				StartCleanupCalled:  true,
 				FindDatastoreCalled: true,
 				FindDatastoreName:   "datastore",
 				FindDatastoreHost:   "host",
@@ -358,7 +360,7 @@ func TestStepAddFloppy_Cleanup(t *testing.T) {
 			multistepState:     multistep.StateHalted,
 			step:               new(StepAddFloppy),
 			driverMock:         new(driver.DriverMock),
-			expectedDriverMock: new(driver.DriverMock),
+			expectedDriverMock: &driver.DriverMock{StartCleanupCalled: true},
 			dsMock:             new(driver.DatastoreMock),
 			expectedDsMock:     new(driver.DatastoreMock),
 			fail:               false,
@@ -385,6 +387,7 @@ func TestStepAddFloppy_Cleanup(t *testing.T) {
 				FindDatastoreErr: fmt.Errorf("fail to find datastore"),
 			},
 			expectedDriverMock: &driver.DriverMock{
+				StartCleanupCalled:  true,
 				FindDatastoreCalled: true,
 				FindDatastoreName:   "datastore",
 				FindDatastoreHost:   "host",
@@ -404,6 +407,7 @@ func TestStepAddFloppy_Cleanup(t *testing.T) {
 			},
 			driverMock: new(driver.DriverMock),
 			expectedDriverMock: &driver.DriverMock{
+				StartCleanupCalled:  true,
 				FindDatastoreCalled: true,
 				FindDatastoreName:   "datastore",
 				FindDatastoreHost:   "host",
@@ -7,24 +7,46 @@
 package common
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"log"
+	"net/url"
 	"reflect"
+	"time"
 
 	"github.com/hashicorp/packer-plugin-sdk/multistep"
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common/utils"
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
 )
 
 type ConnectConfig struct {
 	// The fully qualified domain name or IP address of the vCenter Server
-	// instance.
+	// instance. Defaults to the value of the `VSPHERE_SERVER` environment
+	// variable.
 	VCenterServer string `mapstructure:"vcenter_server"`
+	// The port of the vCenter Server instance. Defaults to `443`, which is
+	// also used if the port is set to `0`.
+	//
+	// -> **Note:** A port included in `vcenter_server`, such as
+	// `vcenter.example.com:8443`, takes precedence over this option.
+	Port int `mapstructure:"port"`
 	// The username to authenticate with the vCenter Server instance.
+	// Defaults to the value of the `VSPHERE_USER` environment variable.
 	Username string `mapstructure:"username"`
 	// The password to authenticate with the vCenter Server instance.
+	// Defaults to the value of the `VSPHERE_PASSWORD` environment variable.
 	Password string `mapstructure:"password"`
+	// The session cookie of an existing vCenter Server session used instead of
+	// `username` and `password`. For example, the value of the
+	// `vmware_soap_session` cookie issued by an external authentication step.
+	// The session is used for the requests to the vSphere Web Services API,
+	// and a session of the vSphere Automation API is created from it.
+	//
+	// -> **Note:** This option cannot be used with `username` or `password`.
+	// The session is not logged out when the build completes.
+	SessionToken string `mapstructure:"session_token"`
 	// Do not validate the certificate of the vCenter Server instance.
 	// Defaults to `false`.
 	//
@@ -36,36 +58,133 @@ type ConnectConfig struct {
 	// -> **Note:** Required if more than one datacenter object exists in the
 	// vSphere inventory.
 	Datacenter string `mapstructure:"datacenter"`
+	// The user agent reported to the vCenter Server instance for each API
+	// call. Defaults to `packer-plugin-vsphere/<version>`.
+	UserAgent string `mapstructure:"user_agent"`
+	// The SHA-1 or SHA-256 thumbprint of the vCenter Server instance
+	// certificate in colon-separated hexadecimal format. For example,
+	// `AB:CD:EF:...`. When set, only a certificate matching the thumbprint is
+	// accepted and `insecure_connection` is ignored.
+	//
+	// -> **Note:** This option cannot be used with `ca_cert_file`.
+	Thumbprint string `mapstructure:"thumbprint"`
+	// The interval of the keep-alive requests sent to the vCenter Server
+	// instance while the session is idle. Defaults to `10m` (10 minutes).
+	// Set to a negative value, such as `-1s`, to disable keep-alive requests.
+	KeepAliveInterval time.Duration `mapstructure:"keep_alive_interval"`
+	// The number of times to retry the connection to the vCenter Server
+	// instance after a transient error, such as a refused connection, a
+	// timeout, or an unavailable service. The delay between attempts starts at
+	// 1 second and doubles after each attempt, up to 30 seconds. Defaults to
+	// `0`.
+	//
+	// -> **Note:** Authentication errors are not retried.
+	ConnectRetries int `mapstructure:"connect_retries"`
+	// The URL of the proxy server used to connect to the vCenter Server
+	// instance. For example, `http://proxy.example.com:3128`. Defaults to the
+	// value of the `HTTPS_PROXY` environment variable, excluding the hosts in
+	// the `NO_PROXY` environment variable.
+	//
+	// -> **Note:** `insecure_connection` and `thumbprint` also apply to
+	// connections through the proxy server.
+	Proxy string `mapstructure:"proxy"`
+	// The path to a PEM-encoded bundle of certificate authorities used to
+	// verify the certificate of the vCenter Server instance. For example,
+	// `/etc/pki/vcenter-ca.pem`. Defaults to the certificate authorities of the
+	// system.
+	//
+	// -> **Note:** This option cannot be used with `insecure_connection` or
+	// `thumbprint`.
+	CACertFile string `mapstructure:"ca_cert_file"`
+	// Log the name and duration of each vSphere API call to the Packer log.
+	// Defaults to `false`, or `true` if the `VSPHERE_DEBUG` environment variable
+	// is set to `true`. Request and response bodies are not logged.
+	Debug bool `mapstructure:"debug"`
+	// The amount of time to wait for each content library request, such as a
+	// lookup, an update, or the preparation of a file for download, before
+	// the operation is aborted. The transfer of a downloaded file is not
+	// bounded. Defaults to `10m` (10 minutes).
+	ContentLibraryTimeout time.Duration `mapstructure:"content_library_timeout"`
 }
 
 func (c *ConnectConfig) Prepare() []error {
 	var errs []error
 
+	c.VCenterServer = cmp.Or(c.VCenterServer, utils.Getenv(utils.EnvVcenterServer))
+	if c.SessionToken != "" {
+		if c.Username != "" || c.Password != "" {
+			errs = append(errs, fmt.Errorf("'session_token' and 'username' or 'password' are mutually exclusive"))
+		}
+	} else {
+		c.Username = cmp.Or(c.Username, utils.Getenv(utils.EnvVsphereUsername))
+		c.Password = cmp.Or(c.Password, utils.Getenv(utils.EnvVspherePassword))
+	}
+
 	if c.VCenterServer == "" {
 		errs = append(errs, fmt.Errorf("'vcenter_server' is required"))
 	}
-	if c.Username == "" {
-		errs = append(errs, fmt.Errorf("'username' is required"))
+	if c.Port < 0 || c.Port > 65535 {
+		errs = append(errs, fmt.Errorf("'port' must be between 1 and 65535, or 0 to use the default port"))
+	}
+	if c.KeepAliveInterval > 0 && c.KeepAliveInterval < time.Second {
+		errs = append(errs, fmt.Errorf("'keep_alive_interval' must be at least 1s, or negative to disable keep-alive requests"))
 	}
-	if c.Password == "" {
-		errs = append(errs, fmt.Errorf("'password' is required"))
+	if c.ContentLibraryTimeout < 0 {
+		errs = append(errs, fmt.Errorf("'content_library_timeout' must not be negative"))
+	}
+	if c.ConnectRetries < 0 {
+		errs = append(errs, fmt.Errorf("'connect_retries' must not be negative"))
+	}
+	if c.Proxy != "" {
+		if u, err := url.Parse(c.Proxy); err != nil || u.Scheme == "" || u.Host == "" {
+			errs = append(errs, fmt.Errorf("'proxy' must be a valid URL, such as 'http://proxy.example.com:3128'"))
+		}
+	}
+	if c.CACertFile != "" && c.InsecureConnection {
+		errs = append(errs, fmt.Errorf("'ca_cert_file' and 'insecure_connection' are mutually exclusive"))
+	}
+	if c.CACertFile != "" && c.Thumbprint != "" {
+		errs = append(errs, fmt.Errorf("'ca_cert_file' and 'thumbprint' are mutually exclusive"))
+	}
+	if c.SessionToken == "" {
+		if c.Username == "" {
+			errs = append(errs, fmt.Errorf("'username' is required"))
+		}
+		if c.Password == "" {
+			errs = append(errs, fmt.Errorf("'password' is required"))
+		}
 	}
 
 	return errs
 }
 
+// NewDriver connects to the vCenter Server instance with the configuration.
+func (c *ConnectConfig) NewDriver(ctx context.Context) (driver.Driver, error) {
+	return driver.NewDriver(ctx, &driver.ConnectConfig{
+		VCenterServer:         c.VCenterServer,
+		Port:                  c.Port,
+		Username:              c.Username,
+		Password:              c.Password,
+		SessionToken:          c.SessionToken,
+		InsecureConnection:    c.InsecureConnection,
+		Datacenter:            c.Datacenter,
+		UserAgent:             c.UserAgent,
+		Thumbprint:            c.Thumbprint,
+		KeepAliveInterval:     c.KeepAliveInterval,
+		ConnectRetries:        c.ConnectRetries,
+		Proxy:                 c.Proxy,
+		CACertFile:            c.CACertFile,
+		Debug:                 c.Debug,
+		ContentLibraryTimeout: c.ContentLibraryTimeout,
+	})
+}
+
 type StepConnect struct {
 	Config *ConnectConfig
 }
 
-func (s *StepConnect) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
-	d, err := driver.NewDriver(&driver.ConnectConfig{
-		VCenterServer:      s.Config.VCenterServer,
-		Username:           s.Config.Username,
-		Password:           s.Config.Password,
-		InsecureConnection: s.Config.InsecureConnection,
-		Datacenter:         s.Config.Datacenter,
-	})
+func (s *StepConnect) Run(ctx context.Context, state multistep.StateBag) multistep.StepAction {
+	d, err := s.Config.NewDriver(ctx)
 	if err != nil {
 		state.Put("error", err)
 		return multistep.ActionHalt
@@ -0,0 +1,271 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common/utils"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestConnectConfig_Prepare(t *testing.T) {
+	tc := []struct {
+		name     string
+		config   *ConnectConfig
+		env      map[string]string
+		expected ConnectConfig
+		fail     bool
+	}{
+		{
+			name: "Should not fail for explicit config",
+			config: &ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				Username:      "administrator@vsphere.local",
+				Password:      "password",
+			},
+			expected: ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				Username:      "administrator@vsphere.local",
+				Password:      "password",
+			},
+		},
+		{
+			name:   "Should fail for empty config",
+			config: new(ConnectConfig),
+			fail:   true,
+		},
+		{
+			name:   "Should use environment variables for empty config",
+			config: new(ConnectConfig),
+			env: map[string]string{
+				utils.EnvVcenterServer:   "vcenter.example.com",
+				utils.EnvVsphereUsername: "administrator@vsphere.local",
+				utils.EnvVspherePassword: "password",
+			},
+			expected: ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				Username:      "administrator@vsphere.local",
+				Password:      "password",
+			},
+		},
+		{
+			name: "Should prefer explicit config over environment variables",
+			config: &ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				Username:      "administrator@vsphere.local",
+			},
+			env: map[string]string{
+				utils.EnvVcenterServer:   "other.example.com",
+				utils.EnvVsphereUsername: "other@vsphere.local",
+				utils.EnvVspherePassword: "password",
+			},
+			expected: ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				Username:      "administrator@vsphere.local",
+				Password:      "password",
+			},
+		},
+		{
+			name:   "Should use deprecated environment variables for empty config",
+			config: new(ConnectConfig),
+			env: map[string]string{
+				utils.EnvVcenterServerDeprecated:   "vcenter.example.com",
+				utils.EnvVsphereUsernameDeprecated: "administrator@vsphere.local",
+				utils.EnvVspherePassword:           "password",
+			},
+			expected: ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				Username:      "administrator@vsphere.local",
+				Password:      "password",
+			},
+		},
+		{
+			name:   "Should prefer environment variables over deprecated environment variables",
+			config: new(ConnectConfig),
+			env: map[string]string{
+				utils.EnvVcenterServer:             "vcenter.example.com",
+				utils.EnvVsphereUsername:           "administrator@vsphere.local",
+				utils.EnvVspherePassword:           "password",
+				utils.EnvVcenterServerDeprecated:   "other.example.com",
+				utils.EnvVsphereUsernameDeprecated: "other@vsphere.local",
+			},
+			expected: ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				Username:      "administrator@vsphere.local",
+				Password:      "password",
+			},
+		},
+		{
+			name: "Should fail for CA certificate file with insecure connection",
+			config: &ConnectConfig{
+				VCenterServer:      "vcenter.example.com",
+				Username:           "administrator@vsphere.local",
+				Password:           "password",
+				InsecureConnection: true,
+				CACertFile:         "/etc/pki/vcenter-ca.pem",
+			},
+			fail: true,
+		},
+		{
+			name: "Should fail for CA certificate file with thumbprint",
+			config: &ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				Username:      "administrator@vsphere.local",
+				Password:      "password",
+				CACertFile:    "/etc/pki/vcenter-ca.pem",
+				Thumbprint:    "AB:CD:EF",
+			},
+			fail: true,
+		},
+		{
+			name: "Should not use environment credentials with session token",
+			config: &ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				SessionToken:  "token",
+			},
+			env: map[string]string{
+				utils.EnvVsphereUsername: "administrator@vsphere.local",
+				utils.EnvVspherePassword: "password",
+			},
+			expected: ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				SessionToken:  "token",
+			},
+		},
+		{
+			name: "Should fail for session token with credentials",
+			config: &ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				Username:      "administrator@vsphere.local",
+				Password:      "password",
+				SessionToken:  "token",
+			},
+			fail: true,
+		},
+		{
+			name: "Should not fail for a port",
+			config: &ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				Port:          8443,
+				Username:      "administrator@vsphere.local",
+				Password:      "password",
+			},
+			expected: ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				Port:          8443,
+				Username:      "administrator@vsphere.local",
+				Password:      "password",
+			},
+		},
+		{
+			name: "Should fail for an out of range port",
+			config: &ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				Port:          65536,
+				Username:      "administrator@vsphere.local",
+				Password:      "password",
+			},
+			fail: true,
+		},
+		{
+			name: "Should fail for invalid proxy URL",
+			config: &ConnectConfig{
+				VCenterServer: "vcenter.example.com",
+				Username:      "administrator@vsphere.local",
+				Password:      "password",
+				Proxy:         "proxy.example.com",
+			},
+			fail: true,
+		},
+		{
+			name: "Should fail for negative content library timeout",
+			config: &ConnectConfig{
+				VCenterServer:         "vcenter.example.com",
+				Username:              "administrator@vsphere.local",
+				Password:              "password",
+				ContentLibraryTimeout: -time.Second,
+			},
+			fail: true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			for _, key := range []string{utils.EnvVcenterServer, utils.EnvVsphereUsername, utils.EnvVspherePassword, utils.EnvVcenterServerDeprecated, utils.EnvVsphereUsernameDeprecated} {
+				t.Setenv(key, c.env[key])
+			}
+
+			errs := c.config.Prepare()
+			if c.fail {
+				if len(errs) == 0 {
+					t.Fatalf("unexpected success: expected failure")
+				}
+				return
+			}
+			if len(errs) != 0 {
+				t.Fatalf("unexpected failure: expected success, but failed: %v", errs)
+			}
+			if *c.config != c.expected {
+				t.Fatalf("unexpected result: expected '%+v', but returned '%+v'", c.expected, *c.config)
+			}
+		})
+	}
+}
+
+func TestStepConnect_Run(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	// The simulator accepts only the empty credentials of its driver.
+	for _, key := range []string{utils.EnvVsphereUsername, utils.EnvVspherePassword, utils.EnvVsphereUsernameDeprecated} {
+		t.Setenv(key, "")
+	}
+	step := &StepConnect{
+		Config: &ConnectConfig{
+			VCenterServer:      sim.server.URL.Host,
+			InsecureConnection: true,
+		},
+	}
+
+	// Canceling the build aborts the connection.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	state := new(multistep.BasicStateBag)
+	if action := step.Run(ctx, state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected result: expected '%#v', but returned '%#v'", multistep.ActionHalt, action)
+	}
+	if err, _ := state.Get("error").(error); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected result: expected '%s', but returned '%v'", context.Canceled, err)
+	}
+
+	// After the build is canceled, the driver is usable by the step cleanups
+	// once the cleanup is started.
+	ctx, cancel = context.WithCancel(context.Background())
+	state = new(multistep.BasicStateBag)
+	state.Put("ui", packersdk.TestUi(t))
+	if action := step.Run(ctx, state); action != multistep.ActionContinue {
+		t.Fatalf("unexpected error: '%v'", state.Get("error"))
+	}
+	cancel()
+
+	_, machine := sim.ChooseSimulatorPreCreatedVM()
+	d := state.Get("driver").(driver.Driver)
+	if _, err := d.FindVM(machine.Name); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected result: expected '%s', but returned '%v'", context.Canceled, err)
+	}
+	d.StartCleanup()
+	if _, err := d.FindVM(machine.Name); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	step.Cleanup(state)
+}
@@ -0,0 +1,86 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/multistep"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/driver"
+)
+
+func TestStepExport_Run(t *testing.T) {
+	tc := []struct {
+		name           string
+		format         string
+		exportErr      error
+		expectedAction multistep.StepAction
+		expectedOva    bool
+	}{
+		{
+			name:           "Export to OVF",
+			format:         "ovf",
+			expectedAction: multistep.ActionContinue,
+		},
+		{
+			name:           "Export to OVF by default",
+			expectedAction: multistep.ActionContinue,
+		},
+		{
+			name:           "Export to OVA",
+			format:         "ova",
+			expectedAction: multistep.ActionContinue,
+			expectedOva:    true,
+		},
+		{
+			name:           "Fail to export",
+			format:         "ovf",
+			exportErr:      fmt.Errorf("export failed"),
+			expectedAction: multistep.ActionHalt,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			driverMock := &driver.DriverMock{ExportToOVFErr: c.exportErr}
+			state := new(multistep.BasicStateBag)
+			state.Put("ui", packersdk.TestUi(t))
+			state.Put("driver", driverMock)
+			state.Put("vm", new(driver.VirtualMachineMock))
+
+			step := &StepExport{
+				Name:      "export",
+				Manifest:  "sha256",
+				OutputDir: "output",
+				Options:   []string{"mac"},
+				Format:    c.format,
+			}
+			if action := step.Run(context.TODO(), state); action != c.expectedAction {
+				t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", c.expectedAction, action)
+			}
+
+			if !driverMock.ExportToOVFCalled {
+				t.Fatalf("unexpected result: expected '%s' to be called", "ExportToOVF")
+			}
+			if driverMock.ExportToOVFDir != step.OutputDir {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", step.OutputDir, driverMock.ExportToOVFDir)
+			}
+			opts := driverMock.ExportToOVFOptions
+			if opts.Name != step.Name || opts.Manifest != step.Manifest || opts.Ova != c.expectedOva {
+				t.Fatalf("unexpected result: expected name '%s', manifest '%s' and ova '%t', but returned '%+v'", step.Name, step.Manifest, c.expectedOva, opts)
+			}
+
+			err, ok := state.GetOk("error")
+			if c.exportErr == nil && ok {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if c.exportErr != nil && err != c.exportErr {
+				t.Fatalf("unexpected result: expected '%s', but returned '%v'", c.exportErr, err)
+			}
+		})
+	}
+}
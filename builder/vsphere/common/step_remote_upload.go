@@ -141,6 +141,7 @@ func (s *StepRemoteUpload) Cleanup(state multistep.StateBag) {
 
 	ui := state.Get("ui").(packersdk.Ui)
 	d := state.Get("driver").(*driver.VCenterDriver)
+	d.StartCleanup()
 	ui.Sayf("Removing %s...", UploadedCDPath)
 
 	ds, err := d.FindDatastore(s.Datastore, s.Host)
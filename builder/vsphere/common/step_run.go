@@ -35,6 +35,7 @@ type StepRun struct {
 func (s *StepRun) Run(_ context.Context, state multistep.StateBag) multistep.StepAction {
 	ui := state.Get("ui").(packersdk.Ui)
 	vm := state.Get("vm").(*driver.VirtualMachineDriver)
+	startCleanup(state)
 
 	if s.Config.BootOrder != "" {
 		ui.Say("Setting boot order...")
@@ -4,6 +4,7 @@
 package testing
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math/rand"
@@ -37,7 +38,7 @@ func TestConn() (driver.Driver, error) {
 	username := utils.GetenvOrDefault(utils.EnvVsphereUsername, utils.DefaultVsphereUsername)
 	password := utils.GetenvOrDefault(utils.EnvVspherePassword, utils.DefaultVspherePassword)
 
-	d, err := driver.NewDriver(&driver.ConnectConfig{
+	d, err := driver.NewDriver(context.Background(), &driver.ConnectConfig{
 		VCenterServer:      vcenter,
 		Username:           username,
 		Password:           password,
@@ -4,6 +4,7 @@
 package utils
 
 import (
+	"log"
 	"os"
 )
 
@@ -13,15 +14,47 @@ const (
 	DefaultVspherePassword = "VMw@re1!"
 	DefaultVsphereHost     = "esxi-01.example.com"
 
-	EnvVcenterServer   = "VSPHERE_VCENTER_SERVER"
-	EnvVsphereUsername = "VSPHERE_USERNAME"
+	// The connection settings are read from these environment variables by
+	// the builders when not configured, and by the tests.
+	EnvVcenterServer   = "VSPHERE_SERVER"
+	EnvVsphereUsername = "VSPHERE_USER"
 	EnvVspherePassword = "VSPHERE_PASSWORD"
+	EnvVsphereDebug    = "VSPHERE_DEBUG"
 	EnvVsphereHost     = "VSPHERE_HOST"
+
+	// The previous names of EnvVcenterServer and EnvVsphereUsername, which
+	// are still read if the current variables are unset.
+	EnvVcenterServerDeprecated   = "VSPHERE_VCENTER_SERVER"
+	EnvVsphereUsernameDeprecated = "VSPHERE_USERNAME"
 )
 
-func GetenvOrDefault(key, defaultValue string) string {
+// deprecatedEnv maps environment variables to their deprecated names.
+var deprecatedEnv = map[string]string{
+	EnvVcenterServer:   EnvVcenterServerDeprecated,
+	EnvVsphereUsername: EnvVsphereUsernameDeprecated,
+}
+
+// Getenv returns the value of the environment variable. If it is empty, the
+// value of the deprecated name of the variable is returned, if any, and a
+// warning is logged.
+func Getenv(key string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
 	}
+	deprecated, ok := deprecatedEnv[key]
+	if !ok {
+		return ""
+	}
+	value := os.Getenv(deprecated)
+	if value != "" {
+		log.Printf("[WARN] The %s environment variable is deprecated and will be removed in the next release. Use %s instead.", deprecated, key)
+	}
+	return value
+}
+
+func GetenvOrDefault(key, defaultValue string) string {
+	if value := Getenv(key); value != "" {
+		return value
+	}
 	return defaultValue
 }
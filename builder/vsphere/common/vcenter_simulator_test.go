@@ -21,13 +21,24 @@ import (
 )
 
 type VCenterSimulator struct {
+	// ctx is used by the driver and is canceled when the simulator is closed.
+	ctx    context.Context
+	cancel context.CancelFunc
 	model  *simulator.Model
 	server *simulator.Server
 	driver *driver.VCenterDriver
 }
 
 func NewCustomVCenterSimulator(model *simulator.Model) (*VCenterSimulator, error) {
+	return NewCustomVCenterSimulatorWithContext(context.Background(), model)
+}
+
+// NewCustomVCenterSimulatorWithContext starts a simulator for the model with
+// a driver bound to ctx, so that a canceled or expired context aborts the
+// connection and the in-flight simulator interactions instead of blocking.
+func NewCustomVCenterSimulatorWithContext(ctx context.Context, model *simulator.Model) (*VCenterSimulator, error) {
 	sim := new(VCenterSimulator)
+	sim.ctx, sim.cancel = context.WithCancel(ctx)
 	sim.model = model
 
 	server, err := sim.NewSimulatorServer()
@@ -47,12 +58,21 @@ func NewCustomVCenterSimulator(model *simulator.Model) (*VCenterSimulator, error
 }
 
 func NewVCenterSimulator() (*VCenterSimulator, error) {
+	return NewVCenterSimulatorWithContext(context.Background())
+}
+
+// NewVCenterSimulatorWithContext starts a simulator with a single virtual
+// machine and a driver bound to ctx.
+func NewVCenterSimulatorWithContext(ctx context.Context) (*VCenterSimulator, error) {
 	model := simulator.VPX()
 	model.Machine = 1
-	return NewCustomVCenterSimulator(model)
+	return NewCustomVCenterSimulatorWithContext(ctx, model)
 }
 
 func (s *VCenterSimulator) Close() {
+	if s.cancel != nil {
+		s.cancel()
+	}
 	if s.model != nil {
 		s.model.Remove()
 	}
@@ -82,7 +102,7 @@ func (s *VCenterSimulator) NewSimulatorServer() (*simulator.Server, error) {
 }
 
 func (s *VCenterSimulator) NewSimulatorDriver() (*driver.VCenterDriver, error) {
-	ctx := context.TODO()
+	ctx := s.ctx
 	user := &url.Userinfo{}
 	s.server.URL.User = user
 
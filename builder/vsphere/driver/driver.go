@@ -4,19 +4,37 @@
 package driver
 
 import (
+	"bytes"
+	"cmp"
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
 	"net/url"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common/utils"
+	"github.com/hashicorp/packer-plugin-vsphere/version"
 	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/fault"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/session"
 	"github.com/vmware/govmomi/vapi/library"
 	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/vim25"
+	"github.com/vmware/govmomi/vim25/methods"
 	"github.com/vmware/govmomi/vim25/soap"
 	"github.com/vmware/govmomi/vim25/types"
 )
@@ -24,9 +42,23 @@ import (
 type Driver interface {
 	NewVM(ref *types.ManagedObjectReference) VirtualMachine
 	FindVM(name string) (VirtualMachine, error)
+	FindVMs(glob string) ([]VirtualMachine, error)
+	FindTemplates(folderPath string, recursive bool) ([]VirtualMachine, error)
+	FindVMByUUID(uuid string, instanceUUID bool) (VirtualMachine, error)
+	FindVMByIP(ip string) (VirtualMachine, error)
+	GetInventoryPath(vm VirtualMachine) (string, error)
 	FindCluster(name string) (*Cluster, error)
 	PreCleanVM(ui packersdk.Ui, vmPath string, force bool, vsphereCluster string, vsphereHost string, vsphereResourcePool string) error
+	PreCleanVMs(ui packersdk.Ui, glob string, force bool, vsphereCluster string, vsphereHost string, vsphereResourcePool string) error
 	CreateVM(config *CreateConfig) (VirtualMachine, error)
+	ValidatePlacement(cluster, host, resourcePool, folder string) error
+	CloneVM(source VirtualMachine, config *CloneConfig) (VirtualMachine, error)
+	GetPowerState(vm VirtualMachine) (types.VirtualMachinePowerState, error)
+	PowerOn(vm VirtualMachine) error
+	PowerOff(vm VirtualMachine) error
+	ListSnapshots(vm VirtualMachine) ([]Snapshot, error)
+	FindSnapshot(vm VirtualMachine, name string) (*Snapshot, error)
+	ExportToOVF(vm VirtualMachine, targetDir string, opts ExportOptions) (string, error)
 
 	NewDatastore(ref *types.ManagedObjectReference) Datastore
 	FindDatastore(name string, host string) (Datastore, error)
@@ -35,29 +67,55 @@ type Driver interface {
 
 	NewFolder(ref *types.ManagedObjectReference) *Folder
 	FindFolder(name string) (*Folder, error)
+	FindFolderByPath(path string) (*Folder, error)
+	CreateFolder(parentPath, name string) (*Folder, error)
 	NewHost(ref *types.ManagedObjectReference) *Host
 	FindHost(name string) (*Host, error)
 	NewNetwork(ref *types.ManagedObjectReference) *Network
 	FindNetwork(name string) (*Network, error)
 	FindNetworks(name string) ([]*Network, error)
+	FindNetworkByType(name string, networkType string) (*Network, error)
 	NewResourcePool(ref *types.ManagedObjectReference) *ResourcePool
 	FindResourcePool(cluster string, host string, name string) (*ResourcePool, error)
+	FindResourcePoolByPath(path string) (*ResourcePool, error)
 
 	FindContentLibraryByName(name string) (*Library, error)
 	FindContentLibraryItem(libraryId string, name string) (*library.Item, error)
+	FindContentLibraryItemByType(libraryId string, name string, itemType string) (*library.Item, error)
 	FindContentLibraryFileDatastorePath(isoPath string) (string, error)
 	UpdateContentLibraryItem(item *library.Item, name string, description string) error
+	AttachTagToLibraryItem(item *library.Item, category, name string) error
+	DownloadContentLibraryItem(ui packersdk.Ui, libraryId, itemName, targetDir string) ([]string, error)
+
+	AttachTag(ref types.ManagedObjectReference, category, name string) error
+	DetachTag(ref types.ManagedObjectReference, category, name string) error
+	FindVMsByTag(category, name string) ([]VirtualMachine, error)
+
+	Version() string
+	StartCleanup()
 	Cleanup() (error, error)
 }
 
 type VCenterDriver struct {
-	// context that controls the authenticated sessions used to run the VM commands
+	// context that controls the authenticated sessions used to run the VM
+	// commands. Operations use context() instead.
 	ctx        context.Context
 	client     *govmomi.Client
 	vimClient  *vim25.Client
 	restClient *RestClient
 	finder     *find.Finder
 	datacenter *object.Datacenter
+	// externalSession is set if the session was provided by the caller and
+	// must not be logged out on cleanup.
+	externalSession bool
+	// libraryTimeout bounds content library operations. Zero uses
+	// DefaultContentLibraryTimeout.
+	libraryTimeout time.Duration
+	// cleanupCtx is the context of the operations of the step cleanups. It is
+	// set by StartCleanup.
+	cleanupMu     sync.Mutex
+	cleanupCtx    context.Context
+	cleanupCancel context.CancelFunc
 }
 
 func NewVCenterDriver(ctx context.Context, client *govmomi.Client, vimClient *vim25.Client, user *url.Userinfo, finder *find.Finder, datacenter *object.Datacenter) *VCenterDriver {
@@ -74,69 +132,517 @@ func NewVCenterDriver(ctx context.Context, client *govmomi.Client, vimClient *vi
 	}
 }
 
+// context returns the context of an operation of the driver. Canceling the
+// context of the driver aborts the operations in flight and those started
+// afterwards, unless the cleanup was started.
+func (d *VCenterDriver) context() context.Context {
+	d.cleanupMu.Lock()
+	defer d.cleanupMu.Unlock()
+	if d.cleanupCtx != nil {
+		return d.cleanupCtx
+	}
+	return d.ctx
+}
+
+// StartCleanup detaches the operations started afterwards from the
+// cancellation of the driver context, so that the build step cleanups can
+// still remove the resources created by a canceled build. The operations are
+// bounded by cleanupTimeout instead.
+func (d *VCenterDriver) StartCleanup() {
+	d.cleanupMu.Lock()
+	defer d.cleanupMu.Unlock()
+	if d.cleanupCtx == nil {
+		d.cleanupCtx, d.cleanupCancel = context.WithTimeout(context.WithoutCancel(d.ctx), cleanupTimeout)
+	}
+}
+
 type ConnectConfig struct {
 	VCenterServer      string
+	Port               int
 	Username           string
 	Password           string
 	InsecureConnection bool
 	Datacenter         string
+	UserAgent          string
+	Thumbprint         string
+	// KeepAliveInterval is the interval of the session keep-alive requests.
+	// Zero uses DefaultKeepAliveInterval and a negative value disables them.
+	KeepAliveInterval time.Duration
+	// ConnectRetries is the number of times a connection is retried after a
+	// transient error.
+	ConnectRetries int
+	// Proxy is the URL of the proxy server used for the connection. The
+	// HTTPS_PROXY and NO_PROXY environment variables are used if unset.
+	Proxy string
+	// CACertFile is the path to a PEM-encoded bundle of certificate
+	// authorities used to verify the certificate of the vCenter Server
+	// instance instead of the system certificate pool. It is not used if a
+	// Thumbprint is set, which replaces the verification of the certificate
+	// chain.
+	CACertFile string
+	// SessionToken is the session cookie of an existing session used instead
+	// of the username and password. The session is not logged out on cleanup.
+	SessionToken string
+	// Debug logs the name and duration of each API call. It is also enabled by
+	// the VSPHERE_DEBUG environment variable.
+	Debug bool
+	// ContentLibraryTimeout bounds each content library lookup and update.
+	// Zero uses DefaultContentLibraryTimeout.
+	ContentLibraryTimeout time.Duration
+}
+
+// connectRetryDelay is the delay before the first connection retry. The delay
+// doubles after each retry, up to maxConnectRetryDelay.
+var connectRetryDelay = time.Second
+
+const maxConnectRetryDelay = 30 * time.Second
+
+// logoutTimeout bounds the logout on cleanup.
+const logoutTimeout = 30 * time.Second
+
+// cleanupTimeout bounds the operations of the build step cleanups after
+// StartCleanup.
+var cleanupTimeout = 10 * time.Minute
+
+// DefaultKeepAliveInterval is the interval of the session keep-alive requests
+// when none is configured.
+const DefaultKeepAliveInterval = 10 * time.Minute
+
+// vcenterURL returns the SDK endpoint URL of the vCenter Server instance. An
+// explicit port in the server address takes precedence over the port argument.
+// The default HTTPS port is omitted from the URL.
+func vcenterURL(server string, port int) (*url.URL, error) {
+	host := server
+	if h, p, err := net.SplitHostPort(server); err == nil {
+		host = h
+		port, err = strconv.Atoi(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port in vCenter Server address '%s'", server)
+		}
+	} else {
+		// A host without a port, including a bracketed or bare IPv6 literal.
+		host = strings.TrimSuffix(strings.TrimPrefix(server, "["), "]")
+	}
+	if host == "" {
+		return nil, fmt.Errorf("invalid vCenter Server address '%s'", server)
+	}
+	if port < 0 || port > 65535 {
+		return nil, fmt.Errorf("invalid vCenter Server port %d", port)
+	}
+
+	if port != 0 && port != 443 {
+		host = net.JoinHostPort(host, strconv.Itoa(port))
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return &url.URL{Scheme: "https", Host: host, Path: "/sdk"}, nil
+}
+
+// DefaultUserAgent returns the user agent reported to vCenter Server when
+// none is configured, identifying the plugin and its version.
+func DefaultUserAgent() string {
+	return fmt.Sprintf("packer-plugin-vsphere/%s", version.PluginVersion.String())
 }
 
-func NewDriver(config *ConnectConfig) (Driver, error) {
-	ctx := context.TODO()
+// NewDriver connects to the vCenter Server instance. The context bounds the
+// connection, including the retries, and the operations of the driver, so
+// canceling it aborts in-flight calls. Operations started after the context is
+// canceled, including the logout of Cleanup, still run.
+func NewDriver(ctx context.Context, config *ConnectConfig) (Driver, error) {
+	vcenterUrl, err := vcenterURL(cmp.Or(config.VCenterServer, utils.Getenv(utils.EnvVcenterServer)), config.Port)
+	if err != nil {
+		return nil, err
+	}
+	var credentials *url.Userinfo
+	if config.SessionToken != "" {
+		if config.Username != "" || config.Password != "" {
+			return nil, fmt.Errorf("a session token cannot be used with a username or password")
+		}
+	} else {
+		credentials = url.UserPassword(cmp.Or(config.Username, utils.Getenv(utils.EnvVsphereUsername)), cmp.Or(config.Password, utils.Getenv(utils.EnvVspherePassword)))
+		vcenterUrl.User = credentials
+	}
+
+	proxy, err := proxyFunc(config.Proxy)
+	if err != nil {
+		return nil, err
+	}
 
-	vcenterUrl, err := url.Parse(fmt.Sprintf("https://%v/sdk", config.VCenterServer))
+	var d *VCenterDriver
+	delay := connectRetryDelay
+	for attempt := 0; ; attempt++ {
+		d, err = connect(ctx, config, vcenterUrl, credentials, proxy)
+		if err == nil || attempt >= config.ConnectRetries || !isTransientError(err) {
+			break
+		}
+		log.Printf("[WARN] Failed to connect to %s: %s; retrying in %s...", vcenterUrl.Host, err, delay)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, maxConnectRetryDelay)
+	}
 	if err != nil {
 		return nil, err
 	}
-	credentials := url.UserPassword(config.Username, config.Password)
-	vcenterUrl.User = credentials
+	log.Printf("[INFO] Connected to vCenter Server %s version %s", vcenterUrl.Host, d.Version())
+	return d, nil
+}
 
+// connect creates the clients, logs in to the vCenter Server instance, and
+// resolves the datacenter.
+func connect(ctx context.Context, config *ConnectConfig, vcenterUrl *url.URL, credentials *url.Userinfo, proxy func(*http.Request) (*url.URL, error)) (*VCenterDriver, error) {
 	soapClient := soap.NewClient(vcenterUrl, config.InsecureConnection)
+	// The REST client shares the transport of the SOAP client.
+	soapClient.DefaultTransport().Proxy = proxy
+	if config.CACertFile != "" {
+		if err := soapClient.SetRootCAs(config.CACertFile); err != nil {
+			return nil, fmt.Errorf("error loading CA certificate file '%s': %s", config.CACertFile, err)
+		}
+	}
+	if config.Thumbprint != "" {
+		// The pinned thumbprint replaces the certificate chain verification.
+		soapClient.DefaultTransport().TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: true,
+			VerifyConnection:   verifyThumbprint(config.Thumbprint),
+		}
+	}
+	soapClient.UserAgent = config.UserAgent
+	if soapClient.UserAgent == "" {
+		soapClient.UserAgent = DefaultUserAgent()
+	}
 	vimClient, err := vim25.NewClient(ctx, soapClient)
 	if err != nil {
 		return nil, err
 	}
+	if debug, _ := strconv.ParseBool(os.Getenv(utils.EnvVsphereDebug)); config.Debug || debug {
+		vimClient.RoundTripper = logRoundTrips(vimClient.RoundTripper)
+	}
 
-	vimClient.RoundTripper = session.KeepAlive(vimClient.RoundTripper, 10*time.Minute)
+	sessionManager := session.NewManager(vimClient)
 	client := &govmomi.Client{
 		Client:         vimClient,
-		SessionManager: session.NewManager(vimClient),
+		SessionManager: sessionManager,
 	}
 
-	err = client.SessionManager.Login(ctx, credentials)
-	if err != nil {
-		return nil, err
+	if config.SessionToken != "" {
+		// An existing session cannot be renewed without credentials.
+		vimClient.RoundTripper = keepAlive(vimClient.RoundTripper, config.KeepAliveInterval)
+		soapClient.Jar.SetCookies(vcenterUrl, []*http.Cookie{{
+			Name:  soap.SessionCookieName,
+			Value: config.SessionToken,
+		}})
+		userSession, err := sessionManager.UserSession(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if userSession == nil {
+			return nil, fmt.Errorf("session token is not valid or the session has expired")
+		}
+	} else {
+		vimClient.RoundTripper = keepAlive(reauthenticate(vimClient.RoundTripper, func() string {
+			if cookie := soapClient.SessionCookie(); cookie != nil {
+				return cookie.Value
+			}
+			return ""
+		}, func(ctx context.Context) error {
+			return sessionManager.Login(ctx, credentials)
+		}), config.KeepAliveInterval)
+		err = client.SessionManager.Login(ctx, credentials)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	finder := find.NewFinder(client.Client, false)
 	datacenter, err := finder.DatacenterOrDefault(ctx, config.Datacenter)
 	if err != nil {
+		if config.SessionToken == "" {
+			// Log out, so that a retried connection does not leave the
+			// session of this attempt behind.
+			logoutCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), logoutTimeout)
+			defer cancel()
+			if lerr := sessionManager.Logout(logoutCtx); lerr != nil {
+				log.Printf("[WARN] Failed to log out after the connection failed: %s", lerr)
+			}
+		}
 		return nil, err
 	}
 	finder.SetDatacenter(datacenter)
 
+	// Without credentials, the REST session is created from the session of
+	// the vSphere Web Services API, whose cookie the REST client inherits.
+	restClient := rest.NewClient(vimClient)
+	restClient.Transport = reauthenticateREST(restClient.Transport, func() string {
+		return restClient.SessionID()
+	}, func(ctx context.Context) error {
+		return restClient.Login(ctx, credentials)
+	})
+
 	d := &VCenterDriver{
 		ctx:       ctx,
 		client:    client,
 		vimClient: vimClient,
 		restClient: &RestClient{
-			client:      rest.NewClient(vimClient),
+			client:      restClient,
 			credentials: credentials,
 		},
-		datacenter: datacenter,
-		finder:     finder,
+		datacenter:      datacenter,
+		finder:          finder,
+		externalSession: config.SessionToken != "",
+		libraryTimeout:  config.ContentLibraryTimeout,
 	}
 	return d, nil
 }
 
+// proxyFunc returns the proxy function of the transport. The proxy server is
+// selected from the environment if none is configured.
+func proxyFunc(proxy string) (func(*http.Request) (*url.URL, error), error) {
+	if proxy == "" {
+		return http.ProxyFromEnvironment, nil
+	}
+	u, err := url.Parse(proxy)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL '%s'", proxy)
+	}
+	return http.ProxyURL(u), nil
+}
+
+// isTransientError reports whether a connection error is likely to be resolved
+// by retrying, such as a refused connection, a timeout, or an unavailable
+// service. Authentication errors are not transient.
+func isTransientError(err error) bool {
+	if vim25.IsTemporaryNetworkError(err) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	if errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		// HTTP status errors are reported with the status as the message.
+		for _, code := range []int{http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout} {
+			if strings.HasPrefix(urlErr.Err.Error(), strconv.Itoa(code)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// keepAlive wraps the round tripper to keep the session alive while idle.
+// Returns the round tripper unchanged if the interval is negative.
+func keepAlive(rt soap.RoundTripper, interval time.Duration) soap.RoundTripper {
+	if interval < 0 {
+		return rt
+	}
+	if interval == 0 {
+		interval = DefaultKeepAliveInterval
+	}
+	return session.KeepAlive(rt, interval)
+}
+
+// debugRoundTripper is a round tripper that logs the method name and duration
+// of each API call. The request and response bodies are not logged, so
+// credentials and session cookies are never written to the log.
+type debugRoundTripper struct {
+	soap.RoundTripper
+}
+
+func logRoundTrips(rt soap.RoundTripper) soap.RoundTripper {
+	return &debugRoundTripper{RoundTripper: rt}
+}
+
+func (r *debugRoundTripper) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	method := strings.TrimSuffix(reflect.TypeOf(req).Elem().Name(), "Body")
+	start := time.Now()
+	err := r.RoundTripper.RoundTrip(ctx, req, res)
+	if err != nil {
+		log.Printf("[DEBUG] vSphere API call %s failed after %s: %s", method, time.Since(start), err)
+		return err
+	}
+	log.Printf("[DEBUG] vSphere API call %s completed in %s", method, time.Since(start))
+	return nil
+}
+
+// reauthenticator is a round tripper that logs in again and retries a request
+// once when it fails because the session is no longer authenticated, such as
+// after the session expired on the server.
+type reauthenticator struct {
+	soap.RoundTripper
+	session func() string
+	login   func(ctx context.Context) error
+	mu      sync.Mutex
+}
+
+func reauthenticate(rt soap.RoundTripper, session func() string, login func(ctx context.Context) error) soap.RoundTripper {
+	return &reauthenticator{RoundTripper: rt, session: session, login: login}
+}
+
+func (r *reauthenticator) RoundTrip(ctx context.Context, req, res soap.HasFault) error {
+	session := r.session()
+	err := r.RoundTripper.RoundTrip(ctx, req, res)
+	if err == nil || !fault.Is(err, &types.NotAuthenticated{}) {
+		return err
+	}
+	switch req.(type) {
+	case *methods.LoginBody, *methods.LogoutBody:
+		return err
+	}
+
+	if lerr := relogin(ctx, &r.mu, r.session, session, r.login); lerr != nil {
+		log.Printf("[WARN] Failed to log in again after the session was no longer authenticated: %s", lerr)
+		return err
+	}
+	log.Printf("[INFO] Logged in again after the session was no longer authenticated; retrying the request.")
+
+	// Reset the response, which holds the fault of the failed request.
+	v := reflect.ValueOf(res).Elem()
+	v.Set(reflect.Zero(v.Type()))
+	return r.RoundTripper.RoundTrip(ctx, req, res)
+}
+
+// restSessionPath is the path of the REST session resource, which is used to
+// log in, log out, and check the session.
+const restSessionPath = "/com/vmware/cis/session"
+
+// restSessionHeader is the header carrying the REST session ID.
+const restSessionHeader = "vmware-api-session-id"
+
+// restReauthenticator is the HTTP round tripper of the REST client that logs in
+// again and retries a request once when it fails because the session is no
+// longer authenticated.
+type restReauthenticator struct {
+	http.RoundTripper
+	session func() string
+	login   func(ctx context.Context) error
+	mu      sync.Mutex
+}
+
+func reauthenticateREST(rt http.RoundTripper, session func() string, login func(ctx context.Context) error) http.RoundTripper {
+	return &restReauthenticator{RoundTripper: rt, session: session, login: login}
+}
+
+func (r *restReauthenticator) RoundTrip(req *http.Request) (*http.Response, error) {
+	// The bodies of API requests, which accept JSON, are small and buffered,
+	// so that the request can be sent again. File transfers are not retried.
+	if req.Header.Get("Accept") != "application/json" || strings.HasSuffix(req.URL.Path, restSessionPath) {
+		return r.RoundTripper.RoundTrip(req)
+	}
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		_ = req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	session := req.Header.Get(restSessionHeader)
+	res, err := r.RoundTripper.RoundTrip(restRequest(req, body, session))
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	if lerr := relogin(req.Context(), &r.mu, r.session, session, r.login); lerr != nil {
+		log.Printf("[WARN] Failed to log in again after the REST session was no longer authenticated: %s", lerr)
+		return res, err
+	}
+	log.Printf("[INFO] Logged in again after the REST session was no longer authenticated; retrying the request.")
+
+	_, _ = io.Copy(io.Discard, res.Body)
+	_ = res.Body.Close()
+	return r.RoundTripper.RoundTrip(restRequest(req, body, r.session()))
+}
+
+// restRequest returns a copy of the request with the buffered body and the
+// session.
+func restRequest(req *http.Request, body []byte, session string) *http.Request {
+	r := req.Clone(req.Context())
+	r.Body = http.NoBody
+	if len(body) > 0 {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	r.ContentLength = int64(len(body))
+	if session != "" {
+		r.Header.Set(restSessionHeader, session)
+	}
+	return r
+}
+
+// relogin logs in again, unless the session no longer matches the session of
+// the failed request, which means that a concurrent request already logged in
+// again. The lock serializes the logins of concurrent requests.
+func relogin(ctx context.Context, mu *sync.Mutex, session func() string, failed string, login func(ctx context.Context) error) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if session() != failed {
+		return nil
+	}
+	return login(ctx)
+}
+
+// verifyThumbprint returns a TLS connection verifier that accepts only a peer
+// certificate matching the SHA-1 or SHA-256 thumbprint.
+func verifyThumbprint(thumbprint string) func(tls.ConnectionState) error {
+	expected := normalizeThumbprint(thumbprint)
+	return func(cs tls.ConnectionState) error {
+		if len(cs.PeerCertificates) == 0 {
+			return fmt.Errorf("no certificate presented by %s", cs.ServerName)
+		}
+		cert := cs.PeerCertificates[0]
+		sha256 := soap.ThumbprintSHA256(cert)
+		if normalizeThumbprint(sha256) == expected || normalizeThumbprint(soap.ThumbprintSHA1(cert)) == expected {
+			return nil
+		}
+		return fmt.Errorf("certificate thumbprint %s does not match the configured thumbprint %s", sha256, thumbprint)
+	}
+}
+
+func normalizeThumbprint(thumbprint string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(thumbprint), ":", ""))
+}
+
+// Version returns the version of the vCenter Server instance, such as
+// "8.0.3", as reported when the session was established.
+func (d *VCenterDriver) Version() string {
+	return d.client.ServiceContent.About.Version
+}
+
+// Cleanup logs out of the REST and SOAP sessions. The logout is not bound to
+// the cancellation of the driver context, so that sessions are not left
+// behind when a build is canceled. A session provided with a session token is
+// not logged out, but the REST session created from it is.
 func (d *VCenterDriver) Cleanup() (error, error) {
-	return d.restClient.client.Logout(d.ctx), d.client.SessionManager.Logout(d.ctx)
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(d.ctx), logoutTimeout)
+	defer cancel()
+	defer func() {
+		d.cleanupMu.Lock()
+		defer d.cleanupMu.Unlock()
+		if d.cleanupCancel != nil {
+			d.cleanupCancel()
+		}
+	}()
+	restErr := d.restClient.Logout(ctx)
+	if d.externalSession {
+		return restErr, nil
+	}
+	return restErr, d.client.SessionManager.Logout(ctx)
 }
 
 // RestClient manages RESTful interactions with vCenter, handling client initialization and credential storage.
 type RestClient struct {
-	client      *rest.Client
+	client *rest.Client
+	// credentials is nil if the driver uses a session token. The REST session
+	// is then created from the session of the vSphere Web Services API.
 	credentials *url.Userinfo
 }
 
@@ -144,6 +650,22 @@ func (r *RestClient) Login(ctx context.Context) error {
 	return r.client.Login(ctx, r.credentials)
 }
 
+// LoginIfNeeded logs in to the REST endpoint unless the client already has a
+// valid session.
+func (r *RestClient) LoginIfNeeded(ctx context.Context) error {
+	s, err := r.client.Session(ctx)
+	if err == nil && s != nil {
+		return nil
+	}
+	return r.Login(ctx)
+}
+
+// Logout deletes the REST session, if one was created. Without a REST
+// session, the request would carry only the cookie of the vSphere Web
+// Services API session, which must not be logged out here.
 func (r *RestClient) Logout(ctx context.Context) error {
+	if r.client.SessionID() == "" {
+		return nil
+	}
 	return r.client.Logout(ctx)
 }
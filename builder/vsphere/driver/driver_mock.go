@@ -5,6 +5,7 @@ package driver
 
 import (
 	"fmt"
+	"path/filepath"
 
 	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/vmware/govmomi/vapi/library"
@@ -18,6 +19,9 @@ type DriverMock struct {
 	FindDatastoreHost   string
 	FindDatastoreErr    error
 
+	ValidatePlacementShouldFail bool
+	ValidatePlacementCalled     bool
+
 	PreCleanShouldFail bool
 	PreCleanVMCalled   bool
 	PreCleanForce      bool
@@ -28,8 +32,20 @@ type DriverMock struct {
 	CreateConfig       *CreateConfig
 	VM                 VirtualMachine
 
+	CloneVMCalled bool
+	CloneVMSource VirtualMachine
+	CloneConfig   *CloneConfig
+	CloneVMErr    error
+
 	FindVMCalled bool
 	FindVMName   string
+
+	ExportToOVFCalled  bool
+	ExportToOVFDir     string
+	ExportToOVFOptions ExportOptions
+	ExportToOVFErr     error
+
+	StartCleanupCalled bool
 }
 
 func NewDriverMock() *DriverMock {
@@ -59,6 +75,26 @@ func (d *DriverMock) FindVM(name string) (VirtualMachine, error) {
 	return d.VM, d.FindDatastoreErr
 }
 
+func (d *DriverMock) FindVMs(glob string) ([]VirtualMachine, error) {
+	return nil, nil
+}
+
+func (d *DriverMock) FindTemplates(folderPath string, recursive bool) ([]VirtualMachine, error) {
+	return nil, nil
+}
+
+func (d *DriverMock) FindVMByUUID(uuid string, instanceUUID bool) (VirtualMachine, error) {
+	return nil, nil
+}
+
+func (d *DriverMock) FindVMByIP(ip string) (VirtualMachine, error) {
+	return nil, nil
+}
+
+func (d *DriverMock) GetInventoryPath(vm VirtualMachine) (string, error) {
+	return "", nil
+}
+
 func (d *DriverMock) FindCluster(name string) (*Cluster, error) {
 	return nil, nil
 }
@@ -73,6 +109,10 @@ func (d *DriverMock) PreCleanVM(ui packersdk.Ui, vmPath string, force bool, vsph
 	return nil
 }
 
+func (d *DriverMock) PreCleanVMs(ui packersdk.Ui, glob string, force bool, vsphereCluster string, vsphereHost string, vsphereResourcePool string) error {
+	return nil
+}
+
 func (d *DriverMock) CreateVM(config *CreateConfig) (VirtualMachine, error) {
 	d.CreateVMCalled = true
 	if d.CreateVMShouldFail {
@@ -83,6 +123,70 @@ func (d *DriverMock) CreateVM(config *CreateConfig) (VirtualMachine, error) {
 	return d.VM, nil
 }
 
+func (d *DriverMock) ValidatePlacement(cluster, host, resourcePool, folder string) error {
+	d.ValidatePlacementCalled = true
+	if d.ValidatePlacementShouldFail {
+		return fmt.Errorf("resource pool '%s' not found", resourcePool)
+	}
+	return nil
+}
+
+func (d *DriverMock) CloneVM(source VirtualMachine, config *CloneConfig) (VirtualMachine, error) {
+	d.CloneVMCalled = true
+	d.CloneVMSource = source
+	d.CloneConfig = config
+	if d.CloneVMErr != nil {
+		return nil, d.CloneVMErr
+	}
+	return d.VM, nil
+}
+
+func (d *DriverMock) GetPowerState(vm VirtualMachine) (types.VirtualMachinePowerState, error) {
+	return "", nil
+}
+
+func (d *DriverMock) PowerOn(vm VirtualMachine) error {
+	return nil
+}
+
+func (d *DriverMock) PowerOff(vm VirtualMachine) error {
+	return nil
+}
+
+func (d *DriverMock) ListSnapshots(vm VirtualMachine) ([]Snapshot, error) {
+	return nil, nil
+}
+
+func (d *DriverMock) FindSnapshot(vm VirtualMachine, name string) (*Snapshot, error) {
+	return nil, nil
+}
+
+func (d *DriverMock) AttachTag(ref types.ManagedObjectReference, category, name string) error {
+	return nil
+}
+
+func (d *DriverMock) DetachTag(ref types.ManagedObjectReference, category, name string) error {
+	return nil
+}
+
+func (d *DriverMock) FindVMsByTag(category, name string) ([]VirtualMachine, error) {
+	return nil, nil
+}
+
+func (d *DriverMock) ExportToOVF(vm VirtualMachine, targetDir string, opts ExportOptions) (string, error) {
+	d.ExportToOVFCalled = true
+	d.ExportToOVFDir = targetDir
+	d.ExportToOVFOptions = opts
+	if d.ExportToOVFErr != nil {
+		return "", d.ExportToOVFErr
+	}
+	ext := ".ovf"
+	if opts.Ova {
+		ext = ".ova"
+	}
+	return filepath.Join(targetDir, opts.Name+ext), nil
+}
+
 func (d *DriverMock) NewDatastore(ref *types.ManagedObjectReference) Datastore { return nil }
 
 func (d *DriverMock) GetDatastoreName(id string) (string, error) { return "", nil }
@@ -95,6 +199,10 @@ func (d *DriverMock) NewFolder(ref *types.ManagedObjectReference) *Folder { retu
 
 func (d *DriverMock) FindFolder(name string) (*Folder, error) { return nil, nil }
 
+func (d *DriverMock) FindFolderByPath(path string) (*Folder, error) { return nil, nil }
+
+func (d *DriverMock) CreateFolder(parentPath, name string) (*Folder, error) { return nil, nil }
+
 func (d *DriverMock) NewHost(ref *types.ManagedObjectReference) *Host { return nil }
 
 func (d *DriverMock) FindHost(name string) (*Host, error) { return nil, nil }
@@ -105,18 +213,30 @@ func (d *DriverMock) FindNetwork(name string) (*Network, error) { return nil, ni
 
 func (d *DriverMock) FindNetworks(name string) ([]*Network, error) { return nil, nil }
 
+func (d *DriverMock) FindNetworkByType(name string, networkType string) (*Network, error) {
+	return nil, nil
+}
+
 func (d *DriverMock) NewResourcePool(ref *types.ManagedObjectReference) *ResourcePool { return nil }
 
 func (d *DriverMock) FindResourcePool(cluster string, host string, name string) (*ResourcePool, error) {
 	return nil, nil
 }
 
+func (d *DriverMock) FindResourcePoolByPath(path string) (*ResourcePool, error) {
+	return nil, nil
+}
+
 func (d *DriverMock) FindContentLibraryByName(name string) (*Library, error) { return nil, nil }
 
 func (d *DriverMock) FindContentLibraryItem(libraryId string, name string) (*library.Item, error) {
 	return nil, nil
 }
 
+func (d *DriverMock) FindContentLibraryItemByType(libraryId string, name string, itemType string) (*library.Item, error) {
+	return nil, nil
+}
+
 func (d *DriverMock) FindContentLibraryFileDatastorePath(isoPath string) (string, error) {
 	return "", nil
 }
@@ -125,6 +245,22 @@ func (d *DriverMock) UpdateContentLibraryItem(item *library.Item, name string, d
 	return nil
 }
 
+func (d *DriverMock) AttachTagToLibraryItem(item *library.Item, category, name string) error {
+	return nil
+}
+
+func (d *DriverMock) DownloadContentLibraryItem(ui packersdk.Ui, libraryId, itemName, targetDir string) ([]string, error) {
+	return nil, nil
+}
+
+func (d *DriverMock) Version() string {
+	return ""
+}
+
+func (d *DriverMock) StartCleanup() {
+	d.StartCleanupCalled = true
+}
+
 func (d *DriverMock) Cleanup() (error, error) {
 	return nil, nil
 }
@@ -4,12 +4,31 @@
 package driver
 
 import (
+	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	cryptorand "crypto/rand"
 	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
 	"fmt"
+	"io"
+	"log"
+	"math/big"
 	"math/rand"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -18,6 +37,7 @@ import (
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/session"
 	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/library"
 	"github.com/vmware/govmomi/vapi/rest"
 	"github.com/vmware/govmomi/vim25"
 	"github.com/vmware/govmomi/vim25/soap"
@@ -28,7 +48,7 @@ func newTestDriver(t *testing.T) Driver {
 	username := utils.GetenvOrDefault(utils.EnvVsphereUsername, utils.DefaultVsphereUsername)
 	password := utils.GetenvOrDefault(utils.EnvVspherePassword, utils.DefaultVspherePassword)
 
-	d, err := NewDriver(&ConnectConfig{
+	d, err := NewDriver(context.Background(), &ConnectConfig{
 		VCenterServer:      vcenter,
 		Username:           username,
 		Password:           password,
@@ -46,13 +66,24 @@ func newVMName() string {
 }
 
 type VCenterSimulator struct {
+	// ctx is used by the driver and is canceled when the simulator is closed.
+	ctx    context.Context
+	cancel context.CancelFunc
 	model  *simulator.Model
 	server *simulator.Server
 	driver *VCenterDriver
 }
 
 func NewCustomVCenterSimulator(model *simulator.Model) (*VCenterSimulator, error) {
+	return NewCustomVCenterSimulatorWithContext(context.Background(), model)
+}
+
+// NewCustomVCenterSimulatorWithContext starts a simulator for the model with
+// a driver bound to ctx, so that a canceled or expired context aborts the
+// connection and the in-flight simulator interactions instead of blocking.
+func NewCustomVCenterSimulatorWithContext(ctx context.Context, model *simulator.Model) (*VCenterSimulator, error) {
 	sim := new(VCenterSimulator)
+	sim.ctx, sim.cancel = context.WithCancel(ctx)
 	sim.model = model
 
 	server, err := sim.NewSimulatorServer()
@@ -72,12 +103,21 @@ func NewCustomVCenterSimulator(model *simulator.Model) (*VCenterSimulator, error
 }
 
 func NewVCenterSimulator() (*VCenterSimulator, error) {
+	return NewVCenterSimulatorWithContext(context.Background())
+}
+
+// NewVCenterSimulatorWithContext starts a simulator with a single virtual
+// machine and a driver bound to ctx.
+func NewVCenterSimulatorWithContext(ctx context.Context) (*VCenterSimulator, error) {
 	model := simulator.VPX()
 	model.Machine = 1
-	return NewCustomVCenterSimulator(model)
+	return NewCustomVCenterSimulatorWithContext(ctx, model)
 }
 
 func (s *VCenterSimulator) Close() {
+	if s.cancel != nil {
+		s.cancel()
+	}
 	if s.model != nil {
 		s.model.Remove()
 	}
@@ -123,7 +163,7 @@ func (s *VCenterSimulator) NewSimulatorServer() (*simulator.Server, error) {
 }
 
 func (s *VCenterSimulator) NewSimulatorDriver() (*VCenterDriver, error) {
-	ctx := context.TODO()
+	ctx := s.ctx
 	user := &url.Userinfo{}
 	s.server.URL.User = user
 
@@ -164,3 +204,1094 @@ func (s *VCenterSimulator) NewSimulatorDriver() (*VCenterDriver, error) {
 	}
 	return d, nil
 }
+
+// newSimulatorProxy starts a vCenter Server simulator behind a TLS reverse
+// proxy that passes each incoming request to inspect before forwarding it.
+func newSimulatorProxy(t *testing.T, inspect func(r *http.Request)) *httptest.Server {
+	return newSimulatorProxyHandler(t, func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		inspect(r)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newSimulatorProxyHandler starts a vCenter Server simulator behind a TLS
+// reverse proxy that passes each incoming request to handler, which decides
+// whether to forward it to the simulator.
+func newSimulatorProxyHandler(t *testing.T, handler func(w http.ResponseWriter, r *http.Request, next http.Handler)) *httptest.Server {
+	model := simulator.VPX()
+	t.Cleanup(model.Remove)
+	if err := model.Create(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	model.Service.RegisterEndpoints = true
+	model.Service.TLS = new(tls.Config)
+	model.Service.ServeMux = http.NewServeMux()
+	server := model.Service.NewServer()
+	t.Cleanup(server.Close)
+
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{Scheme: server.URL.Scheme, Host: server.URL.Host})
+	proxy.Transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	front := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r, proxy)
+	}))
+	t.Cleanup(front.Close)
+	return front
+}
+
+func TestNewDriver_UserAgent(t *testing.T) {
+	tc := []struct {
+		name      string
+		userAgent string
+		expected  string
+	}{
+		{
+			name:     "default",
+			expected: DefaultUserAgent(),
+		},
+		{
+			name:      "custom",
+			userAgent: "packer-test/1.0",
+			expected:  "packer-test/1.0",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			var mu sync.Mutex
+			userAgents := map[string]bool{}
+			front := newSimulatorProxy(t, func(r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				userAgents[r.UserAgent()] = true
+			})
+
+			d, err := NewDriver(context.Background(), &ConnectConfig{
+				VCenterServer:      front.Listener.Addr().String(),
+				Username:           "user",
+				Password:           "pass",
+				InsecureConnection: true,
+				UserAgent:          c.userAgent,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			_, _ = d.Cleanup()
+
+			mu.Lock()
+			defer mu.Unlock()
+			if len(userAgents) != 1 || !userAgents[c.expected] {
+				t.Fatalf("unexpected result: expected only '%s', but received %v", c.expected, userAgents)
+			}
+		})
+	}
+}
+
+func TestNewDriver_Thumbprint(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	cert := sim.server.Certificate()
+	tc := []struct {
+		name       string
+		thumbprint string
+		insecure   bool
+		valid      bool
+	}{
+		{
+			name:       "matching sha256 thumbprint",
+			thumbprint: soap.ThumbprintSHA256(cert),
+			valid:      true,
+		},
+		{
+			name:       "matching sha1 thumbprint in lowercase",
+			thumbprint: strings.ToLower(soap.ThumbprintSHA1(cert)),
+			valid:      true,
+		},
+		{
+			name:       "mismatched thumbprint takes precedence over insecure connection",
+			thumbprint: "AA:BB:CC:DD:EE:FF:00:11:22:33:44:55:66:77:88:99:AA:BB:CC:DD",
+			insecure:   true,
+			valid:      false,
+		},
+		{
+			name:     "unset thumbprint with insecure connection",
+			insecure: true,
+			valid:    true,
+		},
+		{
+			name:  "unset thumbprint with certificate validation",
+			valid: false,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			d, err := NewDriver(context.Background(), &ConnectConfig{
+				VCenterServer:      sim.server.URL.Host,
+				InsecureConnection: c.insecure,
+				Thumbprint:         c.thumbprint,
+			})
+			if c.valid && err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if !c.valid && err == nil {
+				t.Fatalf("unexpected result: expected an error, but connected")
+			}
+			if d != nil {
+				_, _ = d.Cleanup()
+			}
+		})
+	}
+}
+
+func TestVCenterURL(t *testing.T) {
+	tc := []struct {
+		server   string
+		port     int
+		expected string
+		valid    bool
+	}{
+		{server: "vcenter.example.com", expected: "https://vcenter.example.com/sdk", valid: true},
+		{server: "vcenter.example.com", port: 443, expected: "https://vcenter.example.com/sdk", valid: true},
+		{server: "vcenter.example.com", port: 8443, expected: "https://vcenter.example.com:8443/sdk", valid: true},
+		{server: "vcenter.example.com:9443", expected: "https://vcenter.example.com:9443/sdk", valid: true},
+		{server: "vcenter.example.com:9443", port: 8443, expected: "https://vcenter.example.com:9443/sdk", valid: true},
+		{server: "10.0.0.10", port: 8443, expected: "https://10.0.0.10:8443/sdk", valid: true},
+		{server: "fd00::10", expected: "https://[fd00::10]/sdk", valid: true},
+		{server: "[fd00::10]", expected: "https://[fd00::10]/sdk", valid: true},
+		{server: "fd00::10", port: 8443, expected: "https://[fd00::10]:8443/sdk", valid: true},
+		{server: "[fd00::10]:9443", expected: "https://[fd00::10]:9443/sdk", valid: true},
+		{server: "vcenter.example.com:https", valid: false},
+		{server: "vcenter.example.com", port: 70000, valid: false},
+		{server: "", valid: false},
+	}
+
+	for _, c := range tc {
+		u, err := vcenterURL(c.server, c.port)
+		if !c.valid {
+			if err == nil {
+				t.Fatalf("unexpected result: expected an error for '%s' and port %d", c.server, c.port)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if u.String() != c.expected {
+			t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expected, u.String())
+		}
+	}
+}
+
+func TestNewDriver_KeepAliveInterval(t *testing.T) {
+	tc := []struct {
+		name      string
+		interval  time.Duration
+		keepAlive bool
+	}{
+		{
+			name:      "enabled",
+			interval:  100 * time.Millisecond,
+			keepAlive: true,
+		},
+		{
+			name:      "disabled",
+			interval:  -1,
+			keepAlive: false,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			var mu sync.Mutex
+			requests := 0
+			front := newSimulatorProxy(t, func(r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				requests++
+			})
+
+			d, err := NewDriver(context.Background(), &ConnectConfig{
+				VCenterServer:      front.Listener.Addr().String(),
+				Username:           "user",
+				Password:           "pass",
+				InsecureConnection: true,
+				KeepAliveInterval:  c.interval,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			defer func() { _, _ = d.Cleanup() }()
+
+			mu.Lock()
+			before := requests
+			mu.Unlock()
+			time.Sleep(550 * time.Millisecond)
+			mu.Lock()
+			idle := requests - before
+			mu.Unlock()
+
+			if c.keepAlive && idle == 0 {
+				t.Fatalf("unexpected result: expected keep-alive requests while idle")
+			}
+			if !c.keepAlive && idle != 0 {
+				t.Fatalf("unexpected result: expected no requests while idle, but received %d", idle)
+			}
+		})
+	}
+}
+
+func TestNewDriver_EnvCredentials(t *testing.T) {
+	front := newSimulatorProxy(t, func(r *http.Request) {})
+
+	t.Setenv(utils.EnvVcenterServer, front.Listener.Addr().String())
+	t.Setenv(utils.EnvVsphereUsername, "user")
+	t.Setenv(utils.EnvVspherePassword, "pass")
+
+	d, err := NewDriver(context.Background(), &ConnectConfig{
+		InsecureConnection: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	_, _ = d.Cleanup()
+
+	// Explicit configuration takes precedence over the environment.
+	t.Setenv(utils.EnvVcenterServer, "127.0.0.1:1")
+	d, err = NewDriver(context.Background(), &ConnectConfig{
+		VCenterServer:      front.Listener.Addr().String(),
+		InsecureConnection: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	_, _ = d.Cleanup()
+
+	t.Setenv(utils.EnvVcenterServer, front.Listener.Addr().String())
+	_, err = NewDriver(context.Background(), &ConnectConfig{
+		VCenterServer:      "127.0.0.1:1",
+		InsecureConnection: true,
+	})
+	if err == nil {
+		t.Fatalf("unexpected result: expected the configured server to override the environment")
+	}
+
+	// The deprecated environment variables are read if the current ones are
+	// unset.
+	t.Setenv(utils.EnvVcenterServer, "")
+	t.Setenv(utils.EnvVsphereUsername, "")
+	t.Setenv(utils.EnvVcenterServerDeprecated, front.Listener.Addr().String())
+	t.Setenv(utils.EnvVsphereUsernameDeprecated, "user")
+	d, err = NewDriver(context.Background(), &ConnectConfig{
+		InsecureConnection: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	_, _ = d.Cleanup()
+}
+
+func TestNewDriver_ConnectRetries(t *testing.T) {
+	delay := connectRetryDelay
+	connectRetryDelay = 10 * time.Millisecond
+	defer func() { connectRetryDelay = delay }()
+
+	tc := []struct {
+		name        string
+		unavailable int
+		retries     int
+		username    string
+		attempts    int
+		valid       bool
+	}{
+		{
+			name:        "succeeds after transient errors",
+			unavailable: 2,
+			retries:     3,
+			username:    "user",
+			attempts:    3,
+			valid:       true,
+		},
+		{
+			name:        "returns the last error when retries are exhausted",
+			unavailable: 5,
+			retries:     2,
+			username:    "user",
+			attempts:    3,
+			valid:       false,
+		},
+		{
+			name:     "fails fast on authentication errors",
+			retries:  3,
+			username: "",
+			attempts: 1,
+			valid:    false,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			var mu sync.Mutex
+			requests, attempts := 0, 0
+			front := newSimulatorProxyHandler(t, func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+				body, _ := io.ReadAll(r.Body)
+				r.Body = io.NopCloser(bytes.NewReader(body))
+
+				mu.Lock()
+				requests++
+				n := requests
+				// Each connection attempt starts with a request for the service content.
+				if bytes.Contains(body, []byte("RetrieveServiceContent")) {
+					attempts++
+				}
+				mu.Unlock()
+				if n <= c.unavailable {
+					w.WriteHeader(http.StatusServiceUnavailable)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+
+			d, err := NewDriver(context.Background(), &ConnectConfig{
+				VCenterServer:      front.Listener.Addr().String(),
+				Username:           c.username,
+				Password:           "pass",
+				InsecureConnection: true,
+				KeepAliveInterval:  -1,
+				ConnectRetries:     c.retries,
+			})
+			if c.valid && err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if !c.valid && err == nil {
+				t.Fatalf("unexpected result: expected an error, but connected")
+			}
+			if d != nil {
+				_, _ = d.Cleanup()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if attempts != c.attempts {
+				t.Fatalf("unexpected result: expected %d connection attempts, but made %d", c.attempts, attempts)
+			}
+		})
+	}
+}
+
+func TestNewDriver_ConnectRetriesLogout(t *testing.T) {
+	delay := connectRetryDelay
+	connectRetryDelay = 10 * time.Millisecond
+	defer func() { connectRetryDelay = delay }()
+
+	var mu sync.Mutex
+	failed := false
+	logins, logouts := 0, 0
+	front := newSimulatorProxyHandler(t, func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		mu.Lock()
+		switch {
+		case bytes.Contains(body, []byte("<Login ")):
+			logins++
+		case bytes.Contains(body, []byte("<Logout ")):
+			logouts++
+		case bytes.Contains(body, []byte("RetrieveProperties")) && !failed:
+			// Fail the datacenter lookup of the first attempt after the login.
+			failed = true
+			mu.Unlock()
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		mu.Unlock()
+		next.ServeHTTP(w, r)
+	})
+
+	d, err := NewDriver(context.Background(), &ConnectConfig{
+		VCenterServer:      front.Listener.Addr().String(),
+		Username:           "user",
+		Password:           "pass",
+		InsecureConnection: true,
+		KeepAliveInterval:  -1,
+		ConnectRetries:     1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	mu.Lock()
+	if logins != 2 || logouts != 1 {
+		mu.Unlock()
+		t.Fatalf("unexpected result: expected 2 logins and 1 logout, but returned %d and %d", logins, logouts)
+	}
+	mu.Unlock()
+	_, _ = d.Cleanup()
+}
+
+func TestNewDriver_ConnectRetriesCanceled(t *testing.T) {
+	delay := connectRetryDelay
+	connectRetryDelay = time.Minute
+	defer func() { connectRetryDelay = delay }()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	front := newSimulatorProxyHandler(t, func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		// Cancel shortly after the first attempt fails, during the retry
+		// delay.
+		time.AfterFunc(100*time.Millisecond, cancel)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := NewDriver(ctx, &ConnectConfig{
+			VCenterServer:      front.Listener.Addr().String(),
+			Username:           "user",
+			Password:           "pass",
+			InsecureConnection: true,
+			ConnectRetries:     3,
+		})
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected result: expected '%s', but returned '%v'", context.Canceled, err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("unexpected result: expected the retry delay to be interrupted")
+	}
+}
+
+// newConnectProxy starts a stub HTTP proxy that tunnels CONNECT requests and
+// records the address of each tunnel.
+func newConnectProxy(t *testing.T) (*httptest.Server, func() []string) {
+	var mu sync.Mutex
+	var hosts []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "unexpected method", http.StatusMethodNotAllowed)
+			return
+		}
+		mu.Lock()
+		hosts = append(hosts, r.Host)
+		mu.Unlock()
+
+		upstream, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			_ = upstream.Close()
+			return
+		}
+		go func() {
+			_, _ = io.Copy(upstream, conn)
+			_ = upstream.Close()
+		}()
+		_, _ = io.Copy(conn, upstream)
+		_ = conn.Close()
+	}))
+	t.Cleanup(proxy.Close)
+
+	return proxy, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), hosts...)
+	}
+}
+
+func TestNewDriver_Proxy(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	tc := []struct {
+		name       string
+		insecure   bool
+		thumbprint string
+	}{
+		{
+			name:     "insecure connection",
+			insecure: true,
+		},
+		{
+			name:       "thumbprint",
+			thumbprint: soap.ThumbprintSHA256(sim.server.Certificate()),
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			proxy, hosts := newConnectProxy(t)
+
+			d, err := NewDriver(context.Background(), &ConnectConfig{
+				VCenterServer:      sim.server.URL.Host,
+				InsecureConnection: c.insecure,
+				Thumbprint:         c.thumbprint,
+				Proxy:              proxy.URL,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			_, _ = d.Cleanup()
+
+			tunnels := hosts()
+			if len(tunnels) == 0 {
+				t.Fatalf("unexpected result: expected the connection to be routed through the proxy")
+			}
+			for _, host := range tunnels {
+				if host != sim.server.URL.Host {
+					t.Fatalf("unexpected result: expected '%s', but returned '%s'", sim.server.URL.Host, host)
+				}
+			}
+		})
+	}
+
+	if _, err := NewDriver(context.Background(), &ConnectConfig{VCenterServer: sim.server.URL.Host, Proxy: "proxy.example.com"}); err == nil {
+		t.Fatalf("unexpected result: expected an error for an invalid proxy URL")
+	}
+}
+
+// newTestCertificateAuthority returns a PEM-encoded certificate authority and
+// a server certificate for 127.0.0.1 signed by it.
+func newTestCertificateAuthority(t *testing.T) ([]byte, tls.Certificate) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), cryptorand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(cryptorand.Reader, ca, ca, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), cryptorand.Reader)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	leaf := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	leafDER, err := x509.CreateCertificate(cryptorand.Reader, leaf, ca, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), tls.Certificate{
+		Certificate: [][]byte{leafDER},
+		PrivateKey:  key,
+	}
+}
+
+func TestNewDriver_CACertFile(t *testing.T) {
+	caPEM, cert := newTestCertificateAuthority(t)
+	server := newSimulatorProxy(t, func(*http.Request) {})
+	server.TLS.Certificates = []tls.Certificate{cert}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, caPEM, 0600); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	invalidFile := filepath.Join(t.TempDir(), "invalid.pem")
+	if err := os.WriteFile(invalidFile, []byte("invalid"), 0600); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	tc := []struct {
+		name       string
+		caCertFile string
+		valid      bool
+	}{
+		{
+			name:       "certificate authority bundle",
+			caCertFile: caFile,
+			valid:      true,
+		},
+		{
+			name:  "system certificate pool",
+			valid: false,
+		},
+		{
+			name:       "invalid certificate authority bundle",
+			caCertFile: invalidFile,
+			valid:      false,
+		},
+		{
+			name:       "missing certificate authority bundle",
+			caCertFile: filepath.Join(t.TempDir(), "missing.pem"),
+			valid:      false,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			d, err := NewDriver(context.Background(), &ConnectConfig{
+				VCenterServer: server.Listener.Addr().String(),
+				Username:      "user",
+				Password:      "pass",
+				CACertFile:    c.caCertFile,
+			})
+			if c.valid && err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if !c.valid && err == nil {
+				t.Fatalf("unexpected result: expected an error")
+			}
+			if d != nil {
+				_, _ = d.Cleanup()
+			}
+		})
+	}
+}
+
+func TestNewDriver_Reauthenticate(t *testing.T) {
+	var logins int
+	front := newSimulatorProxy(t, func(r *http.Request) {
+		if r.URL.Path != "/sdk" || r.Body == nil {
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if bytes.Contains(body, []byte("<Login")) {
+			logins++
+		}
+	})
+
+	d, err := NewDriver(context.Background(), &ConnectConfig{
+		VCenterServer:      front.Listener.Addr().String(),
+		Username:           "user",
+		Password:           "pass",
+		InsecureConnection: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer func() { _, _ = d.Cleanup() }()
+	vcenter := d.(*VCenterDriver)
+
+	vms, err := d.FindVMs("*")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(vms) == 0 {
+		t.Fatalf("unexpected result: expected at least 1 virtual machine")
+	}
+
+	// Invalidate the session, as if it expired on the server.
+	if err := vcenter.client.SessionManager.Logout(vcenter.ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	if err := vms[0].CreateSnapshot("reauthenticate"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if logins != 2 {
+		t.Fatalf("unexpected result: expected 2 logins, but returned %d", logins)
+	}
+}
+
+func TestNewDriver_ReauthenticateConcurrent(t *testing.T) {
+	var soapLogins, restLogins atomic.Int32
+	front := newSimulatorProxy(t, func(r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/cis/session") && r.Method == http.MethodPost && r.URL.RawQuery == "" {
+			restLogins.Add(1)
+			return
+		}
+		if r.URL.Path != "/sdk" || r.Body == nil {
+			return
+		}
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		if bytes.Contains(body, []byte("<Login")) {
+			soapLogins.Add(1)
+		}
+	})
+
+	d, err := NewDriver(context.Background(), &ConnectConfig{
+		VCenterServer:      front.Listener.Addr().String(),
+		Username:           "user",
+		Password:           "pass",
+		InsecureConnection: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer func() { _, _ = d.Cleanup() }()
+	vcenter := d.(*VCenterDriver)
+
+	vms, err := d.FindVMs("*")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(vms) < 2 {
+		t.Fatalf("unexpected result: expected at least 2 virtual machines")
+	}
+	if err := vcenter.restClient.Login(vcenter.ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// Invalidate both sessions, as if they expired on the server, while the
+	// clients keep sending the expired sessions.
+	if err := vcenter.client.SessionManager.Logout(vcenter.ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	id := vcenter.restClient.client.SessionID()
+	if err := vcenter.restClient.Logout(vcenter.ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	vcenter.restClient.client.SessionID(id)
+
+	// Requests that fail concurrently log in again only once.
+	var wg sync.WaitGroup
+	errs := make(chan error, 2*len(vms))
+	for _, vm := range vms {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			errs <- vm.CreateSnapshot("reauthenticate")
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := library.NewManager(vcenter.restClient.client).ListLibraries(vcenter.ctx)
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+	if n := soapLogins.Load(); n != 2 {
+		t.Fatalf("unexpected result: expected 2 SOAP logins, but returned %d", n)
+	}
+	if n := restLogins.Load(); n != 2 {
+		t.Fatalf("unexpected result: expected 2 REST logins, but returned %d", n)
+	}
+}
+
+// roundTripperFunc adapts a function to an http.RoundTripper.
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestNewDriver_SessionToken(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+	server := sim.server.URL.Host
+
+	// Issue a session cookie, as an external authentication step would.
+	issuer, err := NewDriver(context.Background(), &ConnectConfig{
+		VCenterServer:      server,
+		InsecureConnection: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer func() { _, _ = issuer.Cleanup() }()
+	cookie := issuer.(*VCenterDriver).vimClient.SessionCookie()
+	if cookie == nil {
+		t.Fatalf("unexpected result: expected a session cookie")
+	}
+
+	d, err := NewDriver(context.Background(), &ConnectConfig{
+		VCenterServer:      server,
+		SessionToken:       cookie.Value,
+		InsecureConnection: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	vms, err := d.FindVMs("*")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(vms) == 0 {
+		t.Fatalf("unexpected result: expected at least 1 virtual machine")
+	}
+	if err := vms[0].CreateSnapshot("session-token"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// The REST session is created from the session cookie instead of
+	// reusing the cookie as the REST session ID.
+	var logins []*http.Request
+	rc := d.(*VCenterDriver).restClient.client
+	transport := rc.Transport
+	rc.Transport = roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Header.Get(restSessionHeader) == cookie.Value {
+			t.Errorf("unexpected result: expected the session cookie not to be used as the REST session ID")
+		}
+		if r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, restSessionPath) && r.URL.Query().Get("~action") == "" {
+			logins = append(logins, r)
+		}
+		return transport.RoundTrip(r)
+	})
+	// Unlike vCenter Server, the simulator does not create a REST session
+	// from the session of the vSphere Web Services API, so the login fails.
+	ref := vms[0].(*VirtualMachineDriver).vm.Reference()
+	if err := d.AttachTag(ref, "pipeline", "session-token"); err == nil {
+		t.Fatalf("unexpected result: expected the simulator to reject the REST login")
+	}
+	if len(logins) == 0 {
+		t.Fatalf("unexpected result: expected a REST login")
+	}
+	login := logins[0]
+	if login.Header.Get("vmware-use-header-authn") != "true" {
+		t.Fatalf("unexpected result: expected the REST login to use header authentication")
+	}
+	if _, _, ok := login.BasicAuth(); ok {
+		t.Fatalf("unexpected result: expected the REST login not to use credentials")
+	}
+	if c, err := login.Cookie(soap.SessionCookieName); err != nil || c.Value != cookie.Value {
+		t.Fatalf("unexpected result: expected the REST login to carry the session cookie, but returned '%v'", c)
+	}
+
+	// The session provided by the caller is not logged out on cleanup.
+	if restErr, err := d.Cleanup(); restErr != nil || err != nil {
+		t.Fatalf("unexpected error: '%s', '%s'", restErr, err)
+	}
+	active, err := issuer.(*VCenterDriver).client.SessionManager.SessionIsActive(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if !active {
+		t.Fatalf("unexpected result: expected the session to remain active")
+	}
+
+	tc := []struct {
+		name   string
+		config *ConnectConfig
+	}{
+		{
+			name: "invalid session token",
+			config: &ConnectConfig{
+				SessionToken: "invalid",
+			},
+		},
+		{
+			name: "session token with credentials",
+			config: &ConnectConfig{
+				SessionToken: cookie.Value,
+				Username:     "user",
+				Password:     "pass",
+			},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			c.config.VCenterServer = server
+			c.config.InsecureConnection = true
+			if _, err := NewDriver(context.Background(), c.config); err == nil {
+				t.Fatalf("unexpected result: expected an error")
+			}
+		})
+	}
+}
+
+func TestNewVCenterSimulatorWithContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		sim, err := NewVCenterSimulatorWithContext(ctx)
+		if sim != nil {
+			sim.Close()
+		}
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			t.Fatalf("unexpected result: expected an error for a canceled context")
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("unexpected result: expected the simulator to return promptly for a canceled context")
+	}
+
+	sim, err := NewVCenterSimulatorWithContext(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	sim.Close()
+	if sim.driver.ctx.Err() == nil {
+		t.Fatalf("unexpected result: expected the context to be canceled on close")
+	}
+}
+
+func TestNewDriver_Debug(t *testing.T) {
+	front := newSimulatorProxy(t, func(r *http.Request) {})
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	tc := []struct {
+		name   string
+		debug  bool
+		env    string
+		logged bool
+	}{
+		{
+			name:   "disabled",
+			logged: false,
+		},
+		{
+			name:   "enabled",
+			debug:  true,
+			logged: true,
+		},
+		{
+			name:   "enabled by the environment",
+			env:    "true",
+			logged: true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			buf.Reset()
+			t.Setenv(utils.EnvVsphereDebug, c.env)
+
+			d, err := NewDriver(context.Background(), &ConnectConfig{
+				VCenterServer:      front.Listener.Addr().String(),
+				Username:           "user",
+				Password:           "secret-password",
+				InsecureConnection: true,
+				Debug:              c.debug,
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if _, err := d.FindVMs("*"); err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			_, _ = d.Cleanup()
+
+			output := buf.String()
+			for _, method := range []string{"Login", "RetrievePropertiesEx", "Logout"} {
+				logged := strings.Contains(output, fmt.Sprintf("vSphere API call %s completed", method))
+				if logged != c.logged {
+					t.Fatalf("unexpected result: expected the %s call to be logged: %t, but returned %t", method, c.logged, logged)
+				}
+			}
+			if strings.Contains(output, "secret-password") {
+				t.Fatalf("unexpected result: expected the password not to be logged")
+			}
+		})
+	}
+}
+
+func TestNewDriver_Context(t *testing.T) {
+	var blocked atomic.Bool
+	held := make(chan struct{}, 1)
+	front := newSimulatorProxyHandler(t, func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		if blocked.Load() {
+			// Hold the request until the client aborts it.
+			_, _ = io.Copy(io.Discard, r.Body)
+			held <- struct{}{}
+			<-r.Context().Done()
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewDriver(canceled, &ConnectConfig{
+		VCenterServer:      front.Listener.Addr().String(),
+		Username:           "user",
+		Password:           "pass",
+		InsecureConnection: true,
+	}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected result: expected '%s', but returned '%v'", context.Canceled, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	d, err := NewDriver(ctx, &ConnectConfig{
+		VCenterServer:      front.Listener.Addr().String(),
+		Username:           "user",
+		Password:           "pass",
+		InsecureConnection: true,
+		KeepAliveInterval:  -1,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	blocked.Store(true)
+	done := make(chan error, 1)
+	go func() {
+		_, err := d.FindVMs("*")
+		done <- err
+	}()
+	<-held
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("unexpected result: expected '%s', but returned '%v'", context.Canceled, err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatalf("unexpected result: expected the in-flight call to abort")
+	}
+
+	// Operations started after the cancellation fail, unless the cleanup was
+	// started. The logout of Cleanup still runs.
+	blocked.Store(false)
+	if _, err := d.FindVMs("*"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected result: expected '%s', but returned '%v'", context.Canceled, err)
+	}
+	d.StartCleanup()
+	if _, ok := d.(*VCenterDriver).context().Deadline(); !ok {
+		t.Fatalf("unexpected result: expected the cleanup context to have a deadline")
+	}
+	if _, err := d.FindVMs("*"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if _, err := d.Cleanup(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"path"
 	"strings"
 
+	"github.com/vmware/govmomi/fault"
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
 	"github.com/vmware/govmomi/vim25/mo"
@@ -33,16 +34,16 @@ func (d *VCenterDriver) FindFolder(name string) (*Folder, error) {
 	if name != "" {
 		// If the folder does not exist, create it.
 		parent := ""
-		parentFolder, err := d.finder.Folder(d.ctx, path.Join(d.datacenter.InventoryPath, "vm"))
+		parentFolder, err := d.finder.Folder(d.context(), path.Join(d.datacenter.InventoryPath, "vm"))
 		if err != nil {
 			return nil, err
 		}
 		folders := strings.Split(name, "/")
 		for _, folder := range folders {
 			parent = path.Join(parent, folder)
-			f, err := d.finder.Folder(d.ctx, path.Join(d.datacenter.InventoryPath, "vm", parent))
+			f, err := d.finder.Folder(d.context(), path.Join(d.datacenter.InventoryPath, "vm", parent))
 			if _, ok := err.(*find.NotFoundError); ok {
-				f, err = parentFolder.CreateFolder(d.ctx, folder)
+				f, err = parentFolder.CreateFolder(d.context(), folder)
 			}
 			if err != nil {
 				return nil, err
@@ -51,7 +52,7 @@ func (d *VCenterDriver) FindFolder(name string) (*Folder, error) {
 		}
 	}
 
-	f, err := d.finder.Folder(d.ctx, path.Join(d.datacenter.InventoryPath, "vm", name))
+	f, err := d.finder.Folder(d.context(), path.Join(d.datacenter.InventoryPath, "vm", name))
 	if err != nil {
 		return nil, err
 	}
@@ -62,6 +63,92 @@ func (d *VCenterDriver) FindFolder(name string) (*Folder, error) {
 	}, nil
 }
 
+// validateFolder checks the path of a folder as FindFolder resolves it,
+// without creating the missing folders. The first missing folder and its
+// subfolders can be created unless another object has the name of the folder.
+func (d *VCenterDriver) validateFolder(name string) error {
+	parent := ""
+	for _, folder := range strings.Split(name, "/") {
+		parent = path.Join(parent, folder)
+		folderPath := path.Join(d.datacenter.InventoryPath, "vm", parent)
+		_, err := d.finder.Folder(d.context(), folderPath)
+		if _, ok := err.(*find.NotFoundError); ok {
+			objects, err := d.finder.ManagedObjectList(d.context(), folderPath)
+			if err == nil && len(objects) > 0 {
+				return fmt.Errorf("'%s' is a %s, not a folder", folderPath, objects[0].Object.Reference().Type)
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindFolderByPath locates a virtual machine folder by its path. An absolute
+// path is resolved as a full inventory path, such as
+// `/datacenter/vm/folder/subfolder`. A relative path is resolved from the
+// virtual machine folder of the datacenter. An empty path returns the
+// virtual machine folder of the datacenter.
+func (d *VCenterDriver) FindFolderByPath(folderPath string) (*Folder, error) {
+	f, err := d.finder.Folder(d.context(), d.folderInventoryPath(folderPath))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Folder{
+		folder: f,
+		driver: d,
+	}, nil
+}
+
+// CreateFolder creates a folder with the given name in the folder at the
+// parent path, which is resolved as in FindFolderByPath. If the folder
+// already exists, the existing folder is returned.
+func (d *VCenterDriver) CreateFolder(parentPath, name string) (*Folder, error) {
+	if name == "" || strings.Contains(name, "/") {
+		return nil, fmt.Errorf("invalid folder name: '%s'", name)
+	}
+
+	parent, err := d.FindFolderByPath(parentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := d.FindFolderByPath(path.Join(parent.folder.InventoryPath, name))
+	if err == nil {
+		return existing, nil
+	}
+	if _, ok := err.(*find.NotFoundError); !ok {
+		return nil, err
+	}
+
+	f, err := parent.folder.CreateFolder(d.context(), name)
+	if err != nil {
+		// The folder may have been created concurrently.
+		if fault.Is(err, &types.DuplicateName{}) {
+			return d.FindFolderByPath(path.Join(parent.folder.InventoryPath, name))
+		}
+		return nil, err
+	}
+	f.InventoryPath = path.Join(parent.folder.InventoryPath, name)
+
+	return &Folder{
+		folder: f,
+		driver: d,
+	}, nil
+}
+
+// folderInventoryPath returns the full inventory path of a virtual machine
+// folder path.
+func (d *VCenterDriver) folderInventoryPath(folderPath string) string {
+	if strings.HasPrefix(folderPath, "/") {
+		return folderPath
+	}
+	return path.Join(d.datacenter.InventoryPath, "vm", folderPath)
+}
+
 // Info retrieves properties of the folder object with optional filters specified
 // as parameters. If no parameters are provided, all properties are returned.
 func (f *Folder) Info(params ...string) (*mo.Folder, error) {
@@ -72,7 +159,7 @@ func (f *Folder) Info(params ...string) (*mo.Folder, error) {
 		p = params
 	}
 	var info mo.Folder
-	err := f.folder.Properties(f.driver.ctx, f.folder.Reference(), p, &info)
+	err := f.folder.Properties(f.driver.context(), f.folder.Reference(), p, &info)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,119 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+)
+
+func TestVCenterDriver_FindFolderByPath(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	if _, err := sim.driver.FindFolder("templates/linux/ubuntu"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	tc := []struct {
+		name     string
+		path     string
+		expected string
+		fail     bool
+	}{
+		{
+			name:     "relative nested path",
+			path:     "templates/linux/ubuntu",
+			expected: "templates/linux/ubuntu",
+		},
+		{
+			name:     "absolute inventory path",
+			path:     "/DC0/vm/templates/linux",
+			expected: "templates/linux",
+		},
+		{
+			name:     "virtual machine folder",
+			path:     "",
+			expected: "",
+		},
+		{
+			name: "missing folder",
+			path: "templates/windows",
+			fail: true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			f, err := sim.driver.FindFolderByPath(c.path)
+			if c.fail {
+				if err == nil {
+					t.Fatalf("unexpected result: expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			path, err := f.Path()
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if path != c.expected {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expected, path)
+			}
+		})
+	}
+}
+
+func TestVCenterDriver_CreateFolder(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	parent, err := sim.driver.CreateFolder("", "templates")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	child, err := sim.driver.CreateFolder("templates", "linux")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	path, err := child.Path()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if path != "templates/linux" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "templates/linux", path)
+	}
+
+	// Creating an existing folder returns the existing folder.
+	for _, c := range []struct {
+		parentPath string
+		name       string
+		expected   *Folder
+	}{
+		{"", "templates", parent},
+		{"/DC0/vm/templates", "linux", child},
+	} {
+		f, err := sim.driver.CreateFolder(c.parentPath, c.name)
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if f.folder.Reference() != c.expected.folder.Reference() {
+			t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expected.folder.Reference(), f.folder.Reference())
+		}
+	}
+
+	if _, err := sim.driver.CreateFolder("missing", "linux"); err == nil {
+		t.Fatalf("unexpected result: expected an error for a missing parent folder")
+	}
+	if _, err := sim.driver.CreateFolder("templates", "linux/ubuntu"); err == nil {
+		t.Fatalf("unexpected result: expected an error for an invalid folder name")
+	}
+}
@@ -4,14 +4,63 @@
 package driver
 
 import (
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
 	"log"
+	"net/url"
+	"os"
 	"path"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
 	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
 )
 
+// libraryDownloadPollInterval is the interval between checks of whether a
+// content library file is prepared for download.
+var libraryDownloadPollInterval = time.Second
+
+// DefaultContentLibraryTimeout bounds content library lookups and updates when
+// no timeout is configured.
+const DefaultContentLibraryTimeout = 10 * time.Minute
+
+// contentLibraryTimeout returns the timeout of content library operations.
+func (d *VCenterDriver) contentLibraryTimeout() time.Duration {
+	if d.libraryTimeout > 0 {
+		return d.libraryTimeout
+	}
+	return DefaultContentLibraryTimeout
+}
+
+// libraryContext returns a context for a content library operation that is
+// canceled when the configured timeout elapses.
+func (d *VCenterDriver) libraryContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(d.context(), d.contentLibraryTimeout())
+}
+
+// libraryError returns a timeout error if the content library operation
+// failed because its context expired, or the error otherwise.
+func (d *VCenterDriver) libraryError(ctx context.Context, err error) error {
+	if err != nil && d.ctx.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("content library operation timed out after %s", d.contentLibraryTimeout())
+	}
+	return err
+}
+
 type Library struct {
 	driver  *VCenterDriver
 	library *library.Library
@@ -20,10 +69,13 @@ type Library struct {
 // FindContentLibraryByName retrieves a content library by its name. Returns a
 // Library object or an error if the library is not found.
 func (d *VCenterDriver) FindContentLibraryByName(name string) (*Library, error) {
+	ctx, cancel := d.libraryContext()
+	defer cancel()
+
 	lm := library.NewManager(d.restClient.client)
-	l, err := lm.GetLibraryByName(d.ctx, name)
+	l, err := lm.GetLibraryByName(ctx, name)
 	if err != nil {
-		return nil, err
+		return nil, d.libraryError(ctx, err)
 	}
 	return &Library{
 		library: l,
@@ -35,10 +87,13 @@ func (d *VCenterDriver) FindContentLibraryByName(name string) (*Library, error)
 // the specified library ID.  Returns the library item if found or an error if
 // the item is not found or the retrieval process fails.
 func (d *VCenterDriver) FindContentLibraryItem(libraryId string, name string) (*library.Item, error) {
+	ctx, cancel := d.libraryContext()
+	defer cancel()
+
 	lm := library.NewManager(d.restClient.client)
-	items, err := lm.GetLibraryItems(d.ctx, libraryId)
+	items, err := lm.GetLibraryItems(ctx, libraryId)
 	if err != nil {
-		return nil, err
+		return nil, d.libraryError(ctx, err)
 	}
 	for _, item := range items {
 		if item.Name == name {
@@ -48,6 +103,50 @@ func (d *VCenterDriver) FindContentLibraryItem(libraryId string, name string) (*
 	return nil, fmt.Errorf("content library item %s not found", name)
 }
 
+// Content library item types accepted by FindContentLibraryItemByType.
+var libraryItemTypes = []string{"ovf", "iso", "vm-template"}
+
+// FindContentLibraryItemByType locates a content library item by its name and
+// type: 'ovf', 'iso', or 'vm-template'. If the type is empty, a name shared by
+// more than one item returns an error.
+func (d *VCenterDriver) FindContentLibraryItemByType(libraryId string, name string, itemType string) (*library.Item, error) {
+	if itemType != "" && !slices.Contains(libraryItemTypes, itemType) {
+		return nil, fmt.Errorf("unsupported content library item type '%s'; must be 'ovf', 'iso', or 'vm-template'", itemType)
+	}
+
+	ctx, cancel := d.libraryContext()
+	defer cancel()
+
+	lm := library.NewManager(d.restClient.client)
+	items, err := lm.GetLibraryItems(ctx, libraryId)
+	if err != nil {
+		return nil, d.libraryError(ctx, err)
+	}
+
+	var matches []library.Item
+	for _, item := range items {
+		if item.Name == name && (itemType == "" || item.Type == itemType) {
+			matches = append(matches, item)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		if itemType != "" {
+			return nil, fmt.Errorf("content library item %s of type %s not found", name, itemType)
+		}
+		return nil, fmt.Errorf("content library item %s not found", name)
+	case 1:
+		return &matches[0], nil
+	default:
+		var itemTypes []string
+		for _, item := range matches {
+			itemTypes = append(itemTypes, item.Type)
+		}
+		return nil, fmt.Errorf("content library item %s resolves to more than one item; specify the item type: %s", name, strings.Join(itemTypes, ", "))
+	}
+}
+
 // FindContentLibraryItemUUID retrieves the UUID of a content library item
 //
 //	based on the given library ID and item name. Returns the UUID if found or
@@ -66,7 +165,9 @@ func (d *VCenterDriver) FindContentLibraryItemUUID(libraryId string, name string
 // not identified as a content library path or if the retrieval process fails.
 func (d *VCenterDriver) FindContentLibraryFileDatastorePath(isoPath string) (string, error) {
 	log.Printf("Check if ISO path is a Content Library path")
-	err := d.restClient.Login(d.ctx)
+	ctx, cancel := d.libraryContext()
+	err := d.libraryError(ctx, d.restClient.Login(ctx))
+	cancel()
 	if err != nil {
 		log.Printf("vCenter client not available. ISO path not identified as a Content Library path")
 		return isoPath, err
@@ -107,20 +208,241 @@ func (d *VCenterDriver) FindContentLibraryFileDatastorePath(isoPath string) (str
 		return isoPath, err
 	}
 
-	_ = d.restClient.Logout(d.ctx)
+	ctx, cancel = d.libraryContext()
+	defer cancel()
+	_ = d.restClient.Logout(ctx)
 	return path.Join(libItemDir, isoFilePath), nil
 }
 
 // UpdateContentLibraryItem updates the metadata of a content library item,
 // such as its name and description. Returns an error if the update fails.
 func (d *VCenterDriver) UpdateContentLibraryItem(item *library.Item, name string, description string) error {
+	ctx, cancel := d.libraryContext()
+	defer cancel()
+
 	lm := library.NewManager(d.restClient.client)
 	item.Patch(&library.Item{
 		ID:          item.ID,
 		Name:        name,
 		Description: &description,
 	})
-	return lm.UpdateLibraryItem(d.ctx, item)
+	return d.libraryError(ctx, lm.UpdateLibraryItem(ctx, item))
+}
+
+// AttachTagToLibraryItem attaches the tag with the given name in the given
+// category to a content library item. The category and tag are created if
+// they do not exist.
+func (d *VCenterDriver) AttachTagToLibraryItem(item *library.Item, category, name string) error {
+	if item == nil {
+		return fmt.Errorf("content library item is required")
+	}
+	return d.AttachTag(libraryItemReference(item), category, name)
+}
+
+// libraryItemReference returns the managed object reference used to associate
+// tags with a content library item.
+func libraryItemReference(item *library.Item) types.ManagedObjectReference {
+	return types.ManagedObjectReference{
+		Type:  "com.vmware.content.library.Item",
+		Value: item.ID,
+	}
+}
+
+// DownloadContentLibraryItem downloads the files of the content library item
+// with the given name to the target directory and returns their local paths.
+// Files that already exist locally with the expected size and checksum are
+// not downloaded again, which allows an interrupted download to be resumed.
+// If the content library reports no checksum for a file, only its size is
+// compared. A partially downloaded file is removed if its download fails, and
+// a downloaded file is verified against the reported checksum. Returns an
+// error if the name of a file is not a plain file name, such as `../item.iso`.
+func (d *VCenterDriver) DownloadContentLibraryItem(ui packersdk.Ui, libraryId, itemName, targetDir string) ([]string, error) {
+	ctx, cancel := d.libraryContext()
+	err := d.libraryError(ctx, d.restClient.LoginIfNeeded(ctx))
+	cancel()
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := d.FindContentLibraryItem(libraryId, itemName)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(targetDir, 0750); err != nil {
+		return nil, fmt.Errorf("error creating download directory: %s", err)
+	}
+
+	lm := library.NewManager(d.restClient.client)
+	ctx, cancel = d.libraryContext()
+	session, err := lm.CreateLibraryItemDownloadSession(ctx, library.Session{
+		LibraryItemID: item.ID,
+	})
+	err = d.libraryError(ctx, err)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("error creating download session for %s: %s", itemName, err)
+	}
+	defer func() {
+		ctx, cancel := d.libraryContext()
+		defer cancel()
+		_ = lm.DeleteLibraryItemDownloadSession(ctx, session)
+	}()
+
+	paths, err := d.downloadContentLibraryFiles(ui, lm, item.ID, session, targetDir)
+	if err != nil {
+		ctx, cancel := d.libraryContext()
+		defer cancel()
+		_ = lm.FailLibraryItemDownloadSession(ctx, session)
+		return nil, err
+	}
+	return paths, nil
+}
+
+func (d *VCenterDriver) downloadContentLibraryFiles(ui packersdk.Ui, lm *library.Manager, itemID, session, targetDir string) ([]string, error) {
+	ctx, cancel := d.libraryContext()
+	files, err := lm.ListLibraryItemFiles(ctx, itemID)
+	err = d.libraryError(ctx, err)
+	cancel()
+	if err != nil {
+		return nil, fmt.Errorf("error listing content library item files: %s", err)
+	}
+
+	var paths []string
+	for _, file := range files {
+		target, err := contentLibraryFileTarget(targetDir, file.Name)
+		if err != nil {
+			return nil, err
+		}
+		if stat, err := os.Stat(target); err == nil && file.Size != nil && stat.Size() == *file.Size {
+			if err := verifyContentLibraryFile(target, file.Checksum); err == nil {
+				ui.Sayf("Skipping %s; the file has already been downloaded...", file.Name)
+				paths = append(paths, target)
+				continue
+			}
+			log.Printf("[INFO] Downloading %s again: %s", file.Name, err)
+		}
+
+		ctx, cancel := d.libraryContext()
+		_, err = lm.PrepareLibraryItemDownloadSessionFile(ctx, session, file.Name)
+		err = d.libraryError(ctx, err)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("error preparing %s for download: %s", file.Name, err)
+		}
+
+		info, err := d.waitForContentLibraryFile(lm, session, file.Name)
+		if err != nil {
+			return nil, err
+		}
+		src, err := url.Parse(info.DownloadEndpoint.URI)
+		if err != nil {
+			return nil, fmt.Errorf("error parsing download endpoint of %s: %s", file.Name, err)
+		}
+
+		// The transfer itself is not bounded by the content library timeout,
+		// since its duration depends on the size of the file.
+		ui.Sayf("Downloading %s...", file.Name)
+		var wg sync.WaitGroup
+		download := soap.DefaultDownload
+		download.Progress = uiProgress(ui, file.Name, &wg)
+		err = d.restClient.client.DownloadFile(d.context(), target, src, &download)
+		wg.Wait()
+		if err == nil {
+			err = verifyContentLibraryFile(target, file.Checksum)
+		}
+		if err != nil {
+			_ = os.Remove(target)
+			return nil, fmt.Errorf("error downloading %s: %s", file.Name, err)
+		}
+		paths = append(paths, target)
+	}
+	return paths, nil
+}
+
+// contentLibraryFileTarget returns the local path of a content library file
+// in the target directory. The name is reported by the server, so names that
+// are not plain file names are rejected instead of being written outside the
+// target directory.
+func contentLibraryFileTarget(targetDir, name string) (string, error) {
+	if name == "." || filepath.Base(name) != name || !filepath.IsLocal(name) {
+		return "", fmt.Errorf("invalid content library file name '%s'", name)
+	}
+	return filepath.Join(targetDir, name), nil
+}
+
+// contentLibraryChecksums are the hash algorithms of the checksums reported
+// for content library files.
+var contentLibraryChecksums = map[string]func() hash.Hash{
+	"MD5":    md5.New,
+	"SHA1":   sha1.New,
+	"SHA256": sha256.New,
+	"SHA512": sha512.New,
+}
+
+// verifyContentLibraryFile compares the local file with the checksum reported
+// by the content library. A missing checksum or an unsupported algorithm is
+// not verified.
+func verifyContentLibraryFile(path string, checksum *library.Checksum) error {
+	if checksum == nil || checksum.Checksum == "" {
+		return nil
+	}
+	newHash, ok := contentLibraryChecksums[strings.ToUpper(checksum.Algorithm)]
+	if !ok {
+		log.Printf("[WARN] Unsupported checksum algorithm %s; %s is not verified", checksum.Algorithm, path)
+		return nil
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	h := newHash()
+	if _, err := io.Copy(h, f); err != nil {
+		return err
+	}
+	if sum := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(sum, checksum.Checksum) {
+		return fmt.Errorf("checksum mismatch: expected %s %s, but computed %s", checksum.Algorithm, checksum.Checksum, sum)
+	}
+	return nil
+}
+
+// waitForContentLibraryFile waits until the file is prepared for download and
+// returns its download information. The wait is bounded by the content
+// library timeout.
+func (d *VCenterDriver) waitForContentLibraryFile(lm *library.Manager, session, name string) (*library.DownloadFile, error) {
+	ctx, cancel := d.libraryContext()
+	defer cancel()
+
+	for {
+		info, err := lm.GetLibraryItemDownloadSessionFile(ctx, session, name)
+		if err != nil {
+			return nil, fmt.Errorf("error checking the status of %s: %s", name, d.libraryError(ctx, err))
+		}
+		switch info.Status {
+		case "PREPARED":
+			if info.DownloadEndpoint == nil {
+				return nil, fmt.Errorf("no download endpoint for %s", name)
+			}
+			return info, nil
+		case "ERROR":
+			if info.ErrorMessage != nil {
+				return nil, fmt.Errorf("error preparing %s for download: %s", name, info.ErrorMessage.DefaultMessage)
+			}
+			return nil, fmt.Errorf("error preparing %s for download", name)
+		}
+
+		select {
+		case <-ctx.Done():
+			if d.ctx.Err() == nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				return nil, fmt.Errorf("%s was not prepared for download within %s; the last status was %s",
+					name, d.contentLibraryTimeout(), info.Status)
+			}
+			return nil, ctx.Err()
+		case <-time.After(libraryDownloadPollInterval):
+		}
+	}
 }
 
 type LibraryFilePath struct {
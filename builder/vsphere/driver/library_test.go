@@ -3,7 +3,23 @@
 
 package driver
 
-import "testing"
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/vmware/govmomi/vapi/library"
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/soap"
+)
 
 func TestLibraryFilePath(t *testing.T) {
 	tc := []struct {
@@ -63,3 +79,394 @@ func TestLibraryFilePath(t *testing.T) {
 		}
 	}
 }
+
+// newSimulatorLibraryItem creates a content library with an item containing
+// the given files and returns the library ID and the item.
+func newSimulatorLibraryItem(t *testing.T, sim *VCenterSimulator, files map[string]string) (string, *library.Item) {
+	// The REST endpoint of the simulator rejects empty credentials.
+	sim.driver.restClient.credentials = url.UserPassword("user", "pass")
+	if err := sim.driver.restClient.Login(sim.driver.ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	_, datastore := sim.ChooseSimulatorPreCreatedDatastore()
+	lm := library.NewManager(sim.driver.restClient.client)
+	libraryID, err := lm.CreateLibrary(sim.driver.ctx, library.Library{
+		Name: "library",
+		Type: "LOCAL",
+		Storage: []library.StorageBacking{{
+			DatastoreID: datastore.Reference().Value,
+			Type:        "DATASTORE",
+		}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	itemID, err := lm.CreateLibraryItem(sim.driver.ctx, library.Item{
+		Name:      "template",
+		Type:      "ovf",
+		LibraryID: libraryID,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	if len(files) > 0 {
+		session, err := lm.CreateLibraryItemUpdateSession(sim.driver.ctx, library.Session{LibraryItemID: itemID})
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		for name, content := range files {
+			file, err := lm.AddLibraryItemFile(sim.driver.ctx, session, library.UpdateFile{
+				Name:       name,
+				SourceType: "PUSH",
+				Size:       int64(len(content)),
+			})
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			u, err := url.Parse(file.UploadEndpoint.URI)
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			upload := soap.DefaultUpload
+			upload.ContentLength = int64(len(content))
+			if err := sim.driver.restClient.client.Upload(sim.driver.ctx, strings.NewReader(content), u, &upload); err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+		}
+		if err := lm.CompleteLibraryItemUpdateSession(sim.driver.ctx, session); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+
+	item, err := lm.GetLibraryItem(sim.driver.ctx, itemID)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	return libraryID, item
+}
+
+func TestVCenterDriver_AttachTagToLibraryItem(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, item := newSimulatorLibraryItem(t, sim, nil)
+
+	if err := sim.driver.AttachTagToLibraryItem(item, "pipeline", "nightly"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	m := tags.NewManager(sim.driver.restClient.client)
+	attached, err := m.GetAttachedTags(sim.driver.ctx, libraryItemReference(item))
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(attached) != 1 {
+		t.Fatalf("unexpected result: expected 1 attached tag, but returned %d", len(attached))
+	}
+	if attached[0].Name != "nightly" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "nightly", attached[0].Name)
+	}
+
+	if err := sim.driver.AttachTagToLibraryItem(nil, "pipeline", "nightly"); err == nil {
+		t.Fatalf("unexpected result: expected an error for a missing library item")
+	}
+}
+
+func TestVCenterDriver_DownloadContentLibraryItem(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	files := map[string]string{
+		"template.ovf": "<Envelope/>",
+		"template.mf":  "SHA256(template.ovf)= 00",
+	}
+	libraryID, _ := newSimulatorLibraryItem(t, sim, files)
+
+	dir := t.TempDir()
+	paths, err := sim.driver.DownloadContentLibraryItem(packersdk.TestUi(t), libraryID, "template", dir)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(paths) != len(files) {
+		t.Fatalf("unexpected result: expected %d files, but returned %d", len(files), len(paths))
+	}
+	for _, p := range paths {
+		content, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		expected := files[filepath.Base(p)]
+		if string(content) != expected {
+			t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, content)
+		}
+	}
+
+	// Files that were already downloaded are kept.
+	paths, err = sim.driver.DownloadContentLibraryItem(packersdk.TestUi(t), libraryID, "template", dir)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(paths) != len(files) {
+		t.Fatalf("unexpected result: expected %d files, but returned %d", len(files), len(paths))
+	}
+
+	if _, err := sim.driver.DownloadContentLibraryItem(packersdk.TestUi(t), libraryID, "missing", dir); err == nil {
+		t.Fatalf("unexpected result: expected an error for a missing library item")
+	}
+}
+
+func TestContentLibraryFileTarget(t *testing.T) {
+	dir := t.TempDir()
+	tc := []struct {
+		name  string
+		valid bool
+	}{
+		{name: "template.ovf", valid: true},
+		{name: "template-disk1.vmdk", valid: true},
+		{name: ""},
+		{name: "."},
+		{name: ".."},
+		{name: "../template.ovf"},
+		{name: "../../etc/passwd"},
+		{name: "disks/template.vmdk"},
+		{name: "/tmp/template.ovf"},
+	}
+
+	for _, c := range tc {
+		target, err := contentLibraryFileTarget(dir, c.name)
+		if !c.valid {
+			if err == nil {
+				t.Fatalf("unexpected result: expected an error for '%s', but returned '%s'", c.name, target)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if expected := filepath.Join(dir, c.name); target != expected {
+			t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, target)
+		}
+	}
+}
+
+func TestVerifyContentLibraryFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "template.ovf")
+	if err := os.WriteFile(path, []byte("<Envelope/>"), 0o600); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	tc := []struct {
+		checksum *library.Checksum
+		valid    bool
+	}{
+		{checksum: nil, valid: true},
+		{checksum: &library.Checksum{Algorithm: "SHA256"}, valid: true},
+		{checksum: &library.Checksum{Algorithm: "MD5", Checksum: "9b6b7f6b0d6fbd9f0c86a8ad0d1a2b9d"}, valid: false},
+		{checksum: &library.Checksum{Algorithm: "SHA1", Checksum: "0000000000000000000000000000000000000000"}, valid: false},
+		{checksum: &library.Checksum{Algorithm: "UNKNOWN", Checksum: "00"}, valid: true},
+	}
+	for _, algorithm := range []string{"MD5", "SHA1", "SHA256", "SHA512"} {
+		h := contentLibraryChecksums[algorithm]()
+		h.Write([]byte("<Envelope/>"))
+		sum := hex.EncodeToString(h.Sum(nil))
+		tc = append(tc,
+			struct {
+				checksum *library.Checksum
+				valid    bool
+			}{checksum: &library.Checksum{Algorithm: algorithm, Checksum: sum}, valid: true},
+			struct {
+				checksum *library.Checksum
+				valid    bool
+			}{checksum: &library.Checksum{Algorithm: strings.ToLower(algorithm), Checksum: strings.ToUpper(sum)}, valid: true},
+		)
+	}
+
+	for _, c := range tc {
+		err := verifyContentLibraryFile(path, c.checksum)
+		if c.valid && err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if !c.valid && err == nil {
+			t.Fatalf("unexpected result: expected a checksum mismatch for %s", c.checksum.Algorithm)
+		}
+	}
+}
+
+func TestVCenterDriver_WaitForContentLibraryFile(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, item := newSimulatorLibraryItem(t, sim, map[string]string{"template.ovf": "<Envelope/>"})
+
+	defer func(interval time.Duration) { libraryDownloadPollInterval = interval }(libraryDownloadPollInterval)
+	libraryDownloadPollInterval = 10 * time.Millisecond
+	sim.driver.libraryTimeout = 100 * time.Millisecond
+
+	// The file of the session is never prepared, so the wait times out.
+	lm := library.NewManager(sim.driver.restClient.client)
+	session, err := lm.CreateLibraryItemDownloadSession(sim.driver.ctx, library.Session{LibraryItemID: item.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	_, err = sim.driver.waitForContentLibraryFile(lm, session, "template.ovf")
+	expected := "template.ovf was not prepared for download within 100ms; the last status was UNPREPARED"
+	if err == nil || err.Error() != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%v'", expected, err)
+	}
+
+	if _, err := lm.PrepareLibraryItemDownloadSessionFile(sim.driver.ctx, session, "template.ovf"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	info, err := sim.driver.waitForContentLibraryFile(lm, session, "template.ovf")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if info.Status != "PREPARED" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "PREPARED", info.Status)
+	}
+}
+
+func TestVCenterDriver_FindContentLibraryItemByType(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	libraryID, ovf := newSimulatorLibraryItem(t, sim, nil)
+
+	// Items of other types are renamed after creation to share the name of
+	// the OVF template item.
+	lm := library.NewManager(sim.driver.restClient.client)
+	ids := map[string]string{"ovf": ovf.ID}
+	for _, itemType := range []string{"iso", "vm-template"} {
+		id, err := lm.CreateLibraryItem(sim.driver.ctx, library.Item{
+			Name:      "template-" + itemType,
+			Type:      itemType,
+			LibraryID: libraryID,
+		})
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if err := lm.UpdateLibraryItem(sim.driver.ctx, &library.Item{ID: id, Name: ovf.Name}); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		ids[itemType] = id
+	}
+
+	for itemType, id := range ids {
+		item, err := sim.driver.FindContentLibraryItemByType(libraryID, ovf.Name, itemType)
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if item.ID != id {
+			t.Fatalf("unexpected result: expected '%s', but returned '%s'", id, item.ID)
+		}
+	}
+
+	tc := []struct {
+		name     string
+		itemType string
+	}{
+		{name: ovf.Name, itemType: ""},
+		{name: ovf.Name, itemType: "vmtx"},
+		{name: "missing", itemType: "ovf"},
+	}
+	for _, c := range tc {
+		if _, err := sim.driver.FindContentLibraryItemByType(libraryID, c.name, c.itemType); err == nil {
+			t.Fatalf("unexpected result: expected an error for '%s' of type '%s'", c.name, c.itemType)
+		}
+	}
+}
+
+func TestVCenterDriver_ContentLibraryTimeout(t *testing.T) {
+	// The content library API and the REST login do not respond until the
+	// request is canceled.
+	front := newSimulatorProxyHandler(t, func(w http.ResponseWriter, r *http.Request, next http.Handler) {
+		login := strings.HasSuffix(r.URL.Path, "/cis/session") && r.Method == http.MethodPost
+		if login || strings.Contains(r.URL.Path, "/library") {
+			// The server detects the canceled request once the body is read.
+			_, _ = io.Copy(io.Discard, r.Body)
+			<-r.Context().Done()
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+
+	d, err := NewDriver(context.Background(), &ConnectConfig{
+		VCenterServer:         front.Listener.Addr().String(),
+		Username:              "user",
+		Password:              "pass",
+		InsecureConnection:    true,
+		ContentLibraryTimeout: 100 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer func() { _, _ = d.Cleanup() }()
+
+	tc := []struct {
+		name string
+		call func() error
+	}{
+		{
+			name: "find library",
+			call: func() error {
+				_, err := d.FindContentLibraryByName("library")
+				return err
+			},
+		},
+		{
+			name: "find item",
+			call: func() error {
+				_, err := d.FindContentLibraryItem("library", "item")
+				return err
+			},
+		},
+		{
+			name: "update item",
+			call: func() error {
+				return d.UpdateContentLibraryItem(&library.Item{ID: "item"}, "item", "description")
+			},
+		},
+		{
+			name: "download item",
+			call: func() error {
+				_, err := d.DownloadContentLibraryItem(packersdk.TestUi(t), "library", "item", t.TempDir())
+				return err
+			},
+		},
+		{
+			name: "find file datastore path",
+			call: func() error {
+				_, err := d.FindContentLibraryFileDatastorePath("/library/item/file.iso")
+				return err
+			},
+		},
+	}
+
+	expected := "content library operation timed out after 100ms"
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			err := c.call()
+			if err == nil {
+				t.Fatalf("unexpected result: expected an error")
+			}
+			if err.Error() != expected {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, err)
+			}
+		})
+	}
+}
@@ -28,7 +28,7 @@ func (d *VCenterDriver) NewNetwork(ref *types.ManagedObjectReference) *Network {
 // FindNetwork locates a network by its name within the vCenter context.
 // Returns a Network object or an error if the network is not found.
 func (d *VCenterDriver) FindNetwork(name string) (*Network, error) {
-	n, err := d.finder.Network(d.ctx, name)
+	n, err := d.finder.Network(d.context(), name)
 	if err != nil {
 		return nil, err
 	}
@@ -41,7 +41,7 @@ func (d *VCenterDriver) FindNetwork(name string) (*Network, error) {
 // FindNetworks retrieves a list of networks in the vCenter matching the
 // provided name and returns them as Network objects.
 func (d *VCenterDriver) FindNetworks(name string) ([]*Network, error) {
-	ns, err := d.finder.NetworkList(d.ctx, name)
+	ns, err := d.finder.NetworkList(d.context(), name)
 	if err != nil {
 		return nil, err
 	}
@@ -55,6 +55,65 @@ func (d *VCenterDriver) FindNetworks(name string) ([]*Network, error) {
 	return networks, nil
 }
 
+// Network types accepted by FindNetworkByType and the managed object types
+// of their backings.
+var networkTypes = map[string]string{
+	"standard":    "Network",
+	"distributed": "DistributedVirtualPortgroup",
+	"opaque":      "OpaqueNetwork",
+}
+
+// FindNetworkByType locates a network by its name and type: 'standard' for a
+// standard port group, 'distributed' for a distributed port group, or
+// 'opaque' for an opaque network. If the type is empty, a name that resolves
+// to more than one network returns a MultipleNetworkFoundError.
+func (d *VCenterDriver) FindNetworkByType(name string, networkType string) (*Network, error) {
+	refType := ""
+	if networkType != "" {
+		var ok bool
+		refType, ok = networkTypes[networkType]
+		if !ok {
+			return nil, fmt.Errorf("unsupported network type '%s'; must be 'standard', 'distributed', or 'opaque'", networkType)
+		}
+	}
+
+	networks, err := d.FindNetworks(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []*Network
+	for _, n := range networks {
+		t := n.network.Reference().Type
+		if t == refType || (refType == "" && isNetworkType(t)) {
+			matches = append(matches, n)
+		}
+	}
+
+	switch {
+	case len(matches) == 0 && networkType == "":
+		return nil, fmt.Errorf("network '%s' not found", name)
+	case len(matches) == 0:
+		return nil, fmt.Errorf("network '%s' of type '%s' not found", name, networkType)
+	case len(matches) > 1 && refType == "":
+		return nil, &MultipleNetworkFoundError{name, "specify the network type, inventory path, or id of the network"}
+	case len(matches) > 1:
+		return nil, &MultipleNetworkFoundError{name, "specify the inventory path or id of the network"}
+	}
+	return matches[0], nil
+}
+
+// isNetworkType reports whether the managed object type is the backing of a
+// network type. Distributed switches are not networks.
+func isNetworkType(refType string) bool {
+	for _, t := range networkTypes {
+		if t == refType {
+			return true
+		}
+	}
+	return false
+}
+
 // Info retrieves the properties of the network object with optional filters
 // specified as parameters. If no parameters are provided, all properties are
 // returned.
@@ -72,7 +131,7 @@ func (n *Network) Info(params ...string) (*mo.Network, error) {
 		return nil, fmt.Errorf("unexpected %t network object type", n.network)
 	}
 
-	err := network.Properties(n.driver.ctx, network.Reference(), p, &info)
+	err := network.Properties(n.driver.context(), network.Reference(), p, &info)
 	if err != nil {
 		return nil, err
 	}
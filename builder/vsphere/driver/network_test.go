@@ -0,0 +1,135 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"testing"
+
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestVCenterDriver_FindNetworkByType(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	// Create a distributed port group with the name of the standard port
+	// group in a separate network folder.
+	folders, err := sim.driver.datacenter.Folders(sim.driver.ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	folder, err := folders.NetworkFolder.CreateFolder(sim.driver.ctx, "lab")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	task, err := folder.CreateDVS(sim.driver.ctx, types.DVSCreateSpec{
+		ConfigSpec: &types.VMwareDVSConfigSpec{
+			DVSConfigSpec: types.DVSConfigSpec{Name: "lab-dvs"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	info, err := task.WaitForResult(sim.driver.ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	dvs := object.NewDistributedVirtualSwitch(sim.driver.vimClient, info.Result.(types.ManagedObjectReference))
+	task, err = dvs.AddPortgroup(sim.driver.ctx, []types.DVPortgroupConfigSpec{{Name: "VM Network"}})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := task.Wait(sim.driver.ctx); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	tc := []struct {
+		name        string
+		networkType string
+		expected    string
+		fail        bool
+	}{
+		{
+			name:        "VM Network",
+			networkType: "standard",
+			expected:    "Network",
+		},
+		{
+			name:        "VM Network",
+			networkType: "distributed",
+			expected:    "DistributedVirtualPortgroup",
+		},
+		{
+			name:        "VM Network",
+			networkType: "",
+			fail:        true,
+		},
+		{
+			name:        "VM Network",
+			networkType: "opaque",
+			fail:        true,
+		},
+		{
+			name:        "VM Network",
+			networkType: "nsx",
+			fail:        true,
+		},
+		{
+			name:        "DC0_DVPG0",
+			networkType: "",
+			expected:    "DistributedVirtualPortgroup",
+		},
+		{
+			name:        "DC0_DVPG0",
+			networkType: "standard",
+			fail:        true,
+		},
+	}
+
+	for _, c := range tc {
+		n, err := sim.driver.FindNetworkByType(c.name, c.networkType)
+		if c.fail {
+			if err == nil {
+				t.Fatalf("unexpected result: expected an error for '%s' of type '%s'", c.name, c.networkType)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if n.network.Reference().Type != c.expected {
+			t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expected, n.network.Reference().Type)
+		}
+	}
+
+	_, err = sim.driver.FindNetworkByType("VM Network", "")
+	if _, ok := err.(*MultipleNetworkFoundError); !ok {
+		t.Fatalf("unexpected result: expected a MultipleNetworkFoundError, but returned '%v'", err)
+	}
+
+	// A distributed switch is not a network.
+	notFound := []struct {
+		networkType string
+		expected    string
+	}{
+		{
+			networkType: "",
+			expected:    "network 'lab-dvs' not found",
+		},
+		{
+			networkType: "distributed",
+			expected:    "network 'lab-dvs' of type 'distributed' not found",
+		},
+	}
+	for _, c := range notFound {
+		_, err = sim.driver.FindNetworkByType("lab-dvs", c.networkType)
+		if err == nil || err.Error() != c.expected {
+			t.Fatalf("unexpected result: expected '%s', but returned '%v'", c.expected, err)
+		}
+	}
+}
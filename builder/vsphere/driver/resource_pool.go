@@ -6,6 +6,7 @@ package driver
 import (
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/vmware/govmomi/find"
 	"github.com/vmware/govmomi/object"
@@ -28,9 +29,12 @@ func (d *VCenterDriver) NewResourcePool(ref *types.ManagedObjectReference) *Reso
 }
 
 // FindResourcePool locates a resource pool by its name within a specified
-// cluster or host context in vCenter. It falls back to the default resource
-// pool or a vApp if the specified pool is not found. Returns a ResourcePool
-// object or an error if neither the specified nor default pool is accessible.
+// cluster or host context in vCenter. The cluster takes precedence over the
+// host, and an empty name resolves to the root resource pool (`Resources`) of
+// the cluster or host. It falls back to the default resource pool or a vApp if
+// the specified pool is not found. Returns a ResourcePool object or an error
+// if neither the specified nor default pool is accessible, distinguishing a
+// missing cluster or host from a missing pool.
 func (d *VCenterDriver) FindResourcePool(cluster string, host string, name string) (*ResourcePool, error) {
 	var res string
 	if cluster != "" {
@@ -40,18 +44,18 @@ func (d *VCenterDriver) FindResourcePool(cluster string, host string, name strin
 	}
 
 	resourcePath := fmt.Sprintf("%v/Resources/%v", res, name)
-	p, err := d.finder.ResourcePool(d.ctx, resourcePath)
+	p, err := d.finder.ResourcePool(d.context(), resourcePath)
 	if err != nil {
 		log.Printf("[WARN] %s not found. Looking for default resource pool.", resourcePath)
-		dp, dperr := d.finder.DefaultResourcePool(d.ctx)
+		dp, dperr := d.finder.DefaultResourcePool(d.context())
 		if _, ok := dperr.(*find.NotFoundError); ok {
-			vapp, verr := d.finder.VirtualApp(d.ctx, name)
+			vapp, verr := d.finder.VirtualApp(d.context(), name)
 			if verr != nil {
-				return nil, err
+				return nil, d.resourcePoolError(res, name, err)
 			}
 			dp = vapp.ResourcePool
 		} else if dperr != nil {
-			return nil, err
+			return nil, d.resourcePoolError(res, name, err)
 		}
 		p = dp
 	}
@@ -62,6 +66,66 @@ func (d *VCenterDriver) FindResourcePool(cluster string, host string, name strin
 	}, nil
 }
 
+// FindResourcePoolByPath locates a resource pool by its absolute inventory
+// path. For example, `/datacenter/host/cluster/Resources/pool`. The path of
+// the root resource pool of a cluster or host ends with `Resources`. Returns
+// an error distinguishing a missing cluster or host from a missing pool.
+func (d *VCenterDriver) FindResourcePoolByPath(poolPath string) (*ResourcePool, error) {
+	if !strings.HasPrefix(poolPath, "/") {
+		return nil, fmt.Errorf("resource pool path '%s' must be an absolute inventory path", poolPath)
+	}
+
+	p, err := d.finder.ResourcePool(d.context(), poolPath)
+	if err != nil {
+		compute, name, found := splitResourcePoolPath(poolPath)
+		if !found {
+			return nil, err
+		}
+		return nil, d.resourcePoolError(compute, name, err)
+	}
+
+	return &ResourcePool{
+		pool:   p,
+		driver: d,
+	}, nil
+}
+
+// splitResourcePoolPath splits a resource pool inventory path at the first
+// `Resources` path segment into the path of the cluster or host and the path
+// of the pool within its root resource pool. Segments that only start with
+// `Resources`, such as `Resources-prod`, are not split at.
+func splitResourcePoolPath(poolPath string) (compute, name string, found bool) {
+	for i := 0; ; {
+		j := strings.Index(poolPath[i:], "/Resources")
+		if j < 0 {
+			return "", "", false
+		}
+		i += j
+		rest := poolPath[i+len("/Resources"):]
+		if rest == "" || strings.HasPrefix(rest, "/") {
+			return poolPath[:i], strings.TrimPrefix(rest, "/"), true
+		}
+		i += len("/Resources")
+	}
+}
+
+// resourcePoolError returns a descriptive error for a resource pool that was
+// not found, depending on whether the cluster or host exists.
+func (d *VCenterDriver) resourcePoolError(compute string, name string, err error) error {
+	if _, ok := err.(*find.NotFoundError); !ok {
+		return err
+	}
+	if compute != "" {
+		if _, cerr := d.finder.ComputeResource(d.context(), compute); cerr != nil {
+			return fmt.Errorf("cluster or host '%s' not found: %s", compute, cerr)
+		}
+	}
+	if name == "" {
+		return fmt.Errorf("root resource pool not found in '%s': %s", compute, err)
+	}
+	return fmt.Errorf("resource pool '%s' not found in '%s': %s", name, compute, err)
+}
+
 // Info retrieves the properties of the ResourcePool object with optional
 // filters specified as parameters. If no parameters are provided, all
 // properties are returned.
@@ -73,7 +137,7 @@ func (p *ResourcePool) Info(params ...string) (*mo.ResourcePool, error) {
 		params2 = params
 	}
 	var info mo.ResourcePool
-	err := p.pool.Properties(p.driver.ctx, p.pool.Reference(), params2, &info)
+	err := p.pool.Properties(p.driver.context(), p.pool.Reference(), params2, &info)
 	if err != nil {
 		return nil, err
 	}
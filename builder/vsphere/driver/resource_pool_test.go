@@ -4,6 +4,7 @@
 package driver
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/vmware/govmomi/simulator"
@@ -72,3 +73,168 @@ func TestVCenterDriver_FindResourcePoolStandaloneESX(t *testing.T) {
 		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expectedResourcePool, res.pool.Name())
 	}
 }
+
+func TestVCenterDriver_FindResourcePoolCluster(t *testing.T) {
+	model := simulator.VPX()
+	model.Pool = 1
+	sim, err := NewCustomVCenterSimulator(model)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	tc := []struct {
+		name     string
+		cluster  string
+		host     string
+		pool     string
+		expected string
+		err      string
+	}{
+		{
+			name:     "cluster root pool",
+			cluster:  "DC0_C0",
+			expected: "Resources",
+		},
+		{
+			name:     "cluster named child pool",
+			cluster:  "DC0_C0",
+			pool:     "DC0_C0_RP1",
+			expected: "DC0_C0_RP1",
+		},
+		{
+			name:     "cluster takes precedence over host",
+			cluster:  "DC0_C0",
+			host:     "DC0_H0",
+			pool:     "DC0_C0_RP1",
+			expected: "DC0_C0_RP1",
+		},
+		{
+			name:    "missing cluster",
+			cluster: "missing",
+			err:     "cluster or host 'missing' not found",
+		},
+		{
+			name:    "missing pool within cluster",
+			cluster: "DC0_C0",
+			pool:    "missing",
+			err:     "resource pool 'missing' not found in 'DC0_C0'",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := sim.driver.FindResourcePool(c.cluster, c.host, c.pool)
+			if c.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), c.err) {
+					t.Fatalf("unexpected result: expected '%s', but returned '%v'", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if res.pool.Name() != c.expected {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expected, res.pool.Name())
+			}
+		})
+	}
+}
+
+func TestVCenterDriver_FindResourcePoolByPath(t *testing.T) {
+	model := simulator.VPX()
+	model.Pool = 1
+	sim, err := NewCustomVCenterSimulator(model)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	tc := []struct {
+		name     string
+		path     string
+		expected string
+		err      string
+	}{
+		{
+			name:     "standalone host root pool",
+			path:     "/DC0/host/DC0_H0/Resources",
+			expected: "Resources",
+		},
+		{
+			name:     "cluster root pool",
+			path:     "/DC0/host/DC0_C0/Resources",
+			expected: "Resources",
+		},
+		{
+			name:     "cluster named child pool",
+			path:     "/DC0/host/DC0_C0/Resources/DC0_C0_RP1",
+			expected: "DC0_C0_RP1",
+		},
+		{
+			name: "missing cluster",
+			path: "/DC0/host/missing/Resources",
+			err:  "cluster or host '/DC0/host/missing' not found",
+		},
+		{
+			name: "missing pool within cluster",
+			path: "/DC0/host/DC0_C0/Resources/missing",
+			err:  "resource pool 'missing' not found in '/DC0/host/DC0_C0'",
+		},
+		{
+			name: "missing pool named like the root pool",
+			path: "/DC0/host/DC0_C0/Resources/Resources-prod",
+			err:  "resource pool 'Resources-prod' not found in '/DC0/host/DC0_C0'",
+		},
+		{
+			name: "path without a root pool segment",
+			path: "/DC0/host/DC0_C0/Resources-prod/pool",
+			err:  "resource pool '/DC0/host/DC0_C0/Resources-prod/pool' not found",
+		},
+		{
+			name: "relative path",
+			path: "DC0_C0/Resources",
+			err:  "resource pool path 'DC0_C0/Resources' must be an absolute inventory path",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			res, err := sim.driver.FindResourcePoolByPath(c.path)
+			if c.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), c.err) {
+					t.Fatalf("unexpected result: expected '%s', but returned '%v'", c.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if res.pool.Name() != c.expected {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expected, res.pool.Name())
+			}
+		})
+	}
+}
+
+func TestSplitResourcePoolPath(t *testing.T) {
+	tc := []struct {
+		path    string
+		compute string
+		name    string
+		found   bool
+	}{
+		{path: "/dc/host/cluster/Resources", compute: "/dc/host/cluster", found: true},
+		{path: "/dc/host/cluster/Resources/pool/child", compute: "/dc/host/cluster", name: "pool/child", found: true},
+		{path: "/dc/host/Resources-prod/Resources/pool", compute: "/dc/host/Resources-prod", name: "pool", found: true},
+		{path: "/dc/host/cluster/Resources-prod/pool"},
+		{path: "/dc/host/cluster"},
+	}
+
+	for _, c := range tc {
+		compute, name, found := splitResourcePoolPath(c.path)
+		if compute != c.compute || name != c.name || found != c.found {
+			t.Fatalf("unexpected result: expected ('%s', '%s', %t), but returned ('%s', '%s', %t)", c.compute, c.name, c.found, compute, name, found)
+		}
+	}
+}
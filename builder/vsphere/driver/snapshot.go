@@ -0,0 +1,92 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// Snapshot describes a snapshot in the snapshot tree of a virtual machine.
+type Snapshot struct {
+	Name        string
+	Description string
+	// Path is the names of the parent snapshots followed by the name of the
+	// snapshot, separated by '/'. For example, `base/updates`.
+	Path       string
+	Ref        types.ManagedObjectReference
+	CreateTime time.Time
+	PowerState types.VirtualMachinePowerState
+	// Current is set for the current snapshot of the virtual machine.
+	Current bool
+}
+
+// ListSnapshots returns the snapshots of the virtual machine, with each
+// snapshot followed by its children. Returns an empty slice if the virtual
+// machine has no snapshots.
+func (d *VCenterDriver) ListSnapshots(vm VirtualMachine) ([]Snapshot, error) {
+	info, err := vm.Info("snapshot")
+	if err != nil {
+		return nil, fmt.Errorf("error retrieving snapshots: %s", err)
+	}
+
+	snapshots := []Snapshot{}
+	if info.Snapshot == nil {
+		return snapshots, nil
+	}
+
+	var walk func(trees []types.VirtualMachineSnapshotTree, parent string)
+	walk = func(trees []types.VirtualMachineSnapshotTree, parent string) {
+		for _, tree := range trees {
+			p := path.Join(parent, tree.Name)
+			snapshots = append(snapshots, Snapshot{
+				Name:        tree.Name,
+				Description: tree.Description,
+				Path:        p,
+				Ref:         tree.Snapshot,
+				CreateTime:  tree.CreateTime,
+				PowerState:  tree.State,
+				Current:     info.Snapshot.CurrentSnapshot != nil && *info.Snapshot.CurrentSnapshot == tree.Snapshot,
+			})
+			walk(tree.ChildSnapshotList, p)
+		}
+	}
+	walk(info.Snapshot.RootSnapshotList, "")
+	return snapshots, nil
+}
+
+// FindSnapshot locates a snapshot of the virtual machine by its name or, if
+// the name contains a '/', by its path. Returns an error if the name resolves
+// to more than one snapshot.
+func (d *VCenterDriver) FindSnapshot(vm VirtualMachine, name string) (*Snapshot, error) {
+	snapshots, err := d.ListSnapshots(vm)
+	if err != nil {
+		return nil, err
+	}
+
+	byPath := strings.Contains(name, "/")
+	var matches []Snapshot
+	for _, s := range snapshots {
+		if (byPath && s.Path == strings.Trim(name, "/")) || (!byPath && s.Name == name) {
+			matches = append(matches, s)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("snapshot '%s' not found", name)
+	case 1:
+		return &matches[0], nil
+	default:
+		var paths []string
+		for _, s := range matches {
+			paths = append(paths, s.Path)
+		}
+		return nil, fmt.Errorf("'%s' resolves to more than one snapshot; specify the snapshot path: %s", name, strings.Join(paths, ", "))
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVCenterDriver_ListSnapshots(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+
+	snapshots, err := sim.driver.ListSnapshots(vm)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if snapshots == nil || len(snapshots) != 0 {
+		t.Fatalf("unexpected result: expected an empty slice, but returned '%v'", snapshots)
+	}
+
+	// Each snapshot is created as a child of the current snapshot.
+	for _, name := range []string{"base", "updates", "hardened"} {
+		if err := vm.CreateSnapshot(name); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+
+	snapshots, err = sim.driver.ListSnapshots(vm)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	var paths []string
+	for _, s := range snapshots {
+		paths = append(paths, s.Path)
+	}
+	expected := []string{"base", "base/updates", "base/updates/hardened"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Fatalf("unexpected result: expected '%v', but returned '%v'", expected, paths)
+	}
+	for _, s := range snapshots {
+		if s.Current != (s.Name == "hardened") {
+			t.Fatalf("unexpected result: expected only '%s' to be current, but '%s' is current: %t", "hardened", s.Name, s.Current)
+		}
+	}
+}
+
+func TestVCenterDriver_FindSnapshot(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+	for _, name := range []string{"base", "updates", "base"} {
+		if err := vm.CreateSnapshot(name); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+
+	tc := []struct {
+		name     string
+		expected string
+		fail     bool
+	}{
+		{
+			name:     "updates",
+			expected: "base/updates",
+		},
+		{
+			name:     "base/updates/base",
+			expected: "base/updates/base",
+		},
+		{
+			name:     "/base",
+			expected: "base",
+		},
+		{
+			name: "base",
+			fail: true,
+		},
+		{
+			name: "missing",
+			fail: true,
+		},
+	}
+
+	for _, c := range tc {
+		s, err := sim.driver.FindSnapshot(vm, c.name)
+		if c.fail {
+			if err == nil {
+				t.Fatalf("unexpected result: expected an error for '%s'", c.name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if s.Path != c.expected {
+			t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expected, s.Path)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vmware/govmomi/vapi/tags"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// minTagsVersion is the minimum version of vCenter Server that supports the
+// tagging operations of the REST API.
+const minTagsVersion = "6.5"
+
+// checkTagsSupported returns an error if the version of the vCenter Server
+// instance does not support tagging operations. An unknown version is assumed
+// to be supported.
+func (d *VCenterDriver) checkTagsSupported() error {
+	version := d.Version()
+	if supported, ok := versionAtLeast(version, minTagsVersion); ok && !supported {
+		return fmt.Errorf("tags require vCenter Server %s or later, but the detected version is %s", minTagsVersion, version)
+	}
+	return nil
+}
+
+// versionAtLeast reports whether the dotted version is greater than or equal
+// to the minimum version. Returns false for ok if the version cannot be
+// parsed.
+func versionAtLeast(version string, minimum string) (supported bool, ok bool) {
+	parse := func(v string) ([]int, bool) {
+		var parts []int
+		for _, p := range strings.Split(v, ".") {
+			n, err := strconv.Atoi(p)
+			if err != nil {
+				return nil, false
+			}
+			parts = append(parts, n)
+		}
+		return parts, true
+	}
+
+	v, ok := parse(version)
+	if !ok {
+		return false, false
+	}
+	m, _ := parse(minimum)
+	for i := range m {
+		if i >= len(v) || v[i] < m[i] {
+			return false, true
+		}
+		if v[i] > m[i] {
+			return true, true
+		}
+	}
+	return true, true
+}
+
+// AttachTag attaches the tag with the given name in the given category to the
+// managed object. The category and tag are created if they do not exist.
+func (d *VCenterDriver) AttachTag(ref types.ManagedObjectReference, category, name string) error {
+	if err := d.checkTagsSupported(); err != nil {
+		return err
+	}
+	if err := d.restClient.LoginIfNeeded(d.context()); err != nil {
+		return err
+	}
+
+	m := tags.NewManager(d.restClient.client)
+	categoryID, err := d.findOrCreateCategory(m, category)
+	if err != nil {
+		return err
+	}
+	tagID, err := d.findOrCreateTag(m, categoryID, name)
+	if err != nil {
+		return err
+	}
+
+	if err := m.AttachTag(d.context(), tagID, ref); err != nil {
+		return fmt.Errorf("error attaching tag %s:%s to %s: %s", category, name, ref.Value, err)
+	}
+	return nil
+}
+
+// DetachTag detaches the tag with the given name in the given category from
+// the managed object.
+func (d *VCenterDriver) DetachTag(ref types.ManagedObjectReference, category, name string) error {
+	if err := d.checkTagsSupported(); err != nil {
+		return err
+	}
+	if err := d.restClient.LoginIfNeeded(d.context()); err != nil {
+		return err
+	}
+
+	m := tags.NewManager(d.restClient.client)
+	c, err := m.GetCategory(d.context(), category)
+	if err != nil {
+		return fmt.Errorf("error finding tag category %s: %s", category, err)
+	}
+	tag, err := m.GetTagForCategory(d.context(), name, c.ID)
+	if err != nil {
+		return fmt.Errorf("error finding tag %s:%s: %s", category, name, err)
+	}
+
+	if err := m.DetachTag(d.context(), tag.ID, ref); err != nil {
+		return fmt.Errorf("error detaching tag %s:%s from %s: %s", category, name, ref.Value, err)
+	}
+	return nil
+}
+
+// FindVMsByTag returns the virtual machines with the tag of the given name in
+// the given category attached. Returns an empty slice if the category or tag
+// does not exist or no virtual machine has the tag attached.
+func (d *VCenterDriver) FindVMsByTag(category, name string) ([]VirtualMachine, error) {
+	if err := d.checkTagsSupported(); err != nil {
+		return nil, err
+	}
+	if err := d.restClient.LoginIfNeeded(d.context()); err != nil {
+		return nil, err
+	}
+
+	m := tags.NewManager(d.restClient.client)
+	tagID, err := d.findTag(m, category, name)
+	if err != nil {
+		return nil, err
+	}
+
+	vms := []VirtualMachine{}
+	if tagID == "" {
+		return vms, nil
+	}
+
+	refs, err := m.ListAttachedObjects(d.context(), tagID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing objects with tag %s:%s: %s", category, name, err)
+	}
+	for _, ref := range refs {
+		r := ref.Reference()
+		if r.Type != "VirtualMachine" {
+			continue
+		}
+		vms = append(vms, d.NewVM(&r))
+	}
+	return vms, nil
+}
+
+// findTag returns the ID of the tag with the given name in the category, or
+// an empty string if the category or tag does not exist.
+func (d *VCenterDriver) findTag(m *tags.Manager, category, name string) (string, error) {
+	categories, err := m.GetCategories(d.context())
+	if err != nil {
+		return "", fmt.Errorf("error listing tag categories: %s", err)
+	}
+	for _, c := range categories {
+		if c.Name != category {
+			continue
+		}
+		existing, err := m.GetTagsForCategory(d.context(), c.ID)
+		if err != nil {
+			return "", fmt.Errorf("error listing tags: %s", err)
+		}
+		for _, t := range existing {
+			if t.Name == name {
+				return t.ID, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// findOrCreateCategory returns the ID of the tag category with the given
+// name, creating the category if it does not exist.
+func (d *VCenterDriver) findOrCreateCategory(m *tags.Manager, name string) (string, error) {
+	categories, err := m.GetCategories(d.context())
+	if err != nil {
+		return "", fmt.Errorf("error listing tag categories: %s", err)
+	}
+	for _, c := range categories {
+		if c.Name == name {
+			return c.ID, nil
+		}
+	}
+
+	id, err := m.CreateCategory(d.context(), &tags.Category{
+		Name:            name,
+		Cardinality:     "MULTIPLE",
+		AssociableTypes: []string{},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error creating tag category %s: %s", name, err)
+	}
+	return id, nil
+}
+
+// findOrCreateTag returns the ID of the tag with the given name in the
+// category, creating the tag if it does not exist.
+func (d *VCenterDriver) findOrCreateTag(m *tags.Manager, categoryID, name string) (string, error) {
+	existing, err := m.GetTagsForCategory(d.context(), categoryID)
+	if err != nil {
+		return "", fmt.Errorf("error listing tags: %s", err)
+	}
+	for _, t := range existing {
+		if t.Name == name {
+			return t.ID, nil
+		}
+	}
+
+	id, err := m.CreateTag(d.context(), &tags.Tag{
+		Name:       name,
+		CategoryID: categoryID,
+	})
+	if err != nil {
+		return "", fmt.Errorf("error creating tag %s: %s", name, err)
+	}
+	return id, nil
+}
@@ -0,0 +1,184 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vapi/tags"
+
+	_ "github.com/vmware/govmomi/vapi/simulator"
+)
+
+func TestVCenterDriver_AttachTag(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	// The REST endpoint of the simulator rejects empty credentials.
+	sim.driver.restClient.credentials = url.UserPassword("user", "pass")
+
+	_, machine := sim.ChooseSimulatorPreCreatedVM()
+	ref := machine.Reference()
+
+	// Attaching the same tag twice reuses the category and tag created by the
+	// first call.
+	for i := 0; i < 2; i++ {
+		if err := sim.driver.AttachTag(ref, "pipeline", "nightly"); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+
+	m := tags.NewManager(sim.driver.restClient.client)
+	categories, err := m.GetCategories(sim.driver.ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(categories) != 1 {
+		t.Fatalf("unexpected result: expected 1 category, but returned %d", len(categories))
+	}
+
+	attached, err := m.GetAttachedTags(sim.driver.ctx, ref)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(attached) != 1 {
+		t.Fatalf("unexpected result: expected 1 attached tag, but returned %d", len(attached))
+	}
+	if attached[0].Name != "nightly" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "nightly", attached[0].Name)
+	}
+	if attached[0].CategoryID != categories[0].ID {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", categories[0].ID, attached[0].CategoryID)
+	}
+
+	if err := sim.driver.DetachTag(ref, "pipeline", "nightly"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	attached, err = m.GetAttachedTags(sim.driver.ctx, ref)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(attached) != 0 {
+		t.Fatalf("unexpected result: expected no attached tags, but returned %d", len(attached))
+	}
+
+	if err := sim.driver.DetachTag(ref, "missing", "nightly"); err == nil {
+		t.Fatalf("unexpected result: expected an error for a missing category")
+	}
+}
+
+func TestVCenterDriver_FindVMsByTag(t *testing.T) {
+	model := simulator.VPX()
+	model.Machine = 3
+	sim, err := NewCustomVCenterSimulator(model)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	// The REST endpoint of the simulator rejects empty credentials.
+	sim.driver.restClient.credentials = url.UserPassword("user", "pass")
+
+	machines := sim.model.Map().All("VirtualMachine")
+	if len(machines) < 3 {
+		t.Fatalf("unexpected result: expected at least 3 virtual machines, but returned %d", len(machines))
+	}
+
+	expected := map[string]bool{}
+	for _, machine := range machines[:2] {
+		if err := sim.driver.AttachTag(machine.Reference(), "lifecycle", "ephemeral"); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		expected[machine.Reference().Value] = true
+	}
+
+	// Objects other than virtual machines are not returned.
+	_, host := sim.ChooseSimulatorPreCreatedHost()
+	if err := sim.driver.AttachTag(host.Reference(), "lifecycle", "ephemeral"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	vms, err := sim.driver.FindVMsByTag("lifecycle", "ephemeral")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(vms) != len(expected) {
+		t.Fatalf("unexpected result: expected %d virtual machines, but returned %d", len(expected), len(vms))
+	}
+	for _, vm := range vms {
+		ref := vm.(*VirtualMachineDriver).vm.Reference()
+		if !expected[ref.Value] {
+			t.Fatalf("unexpected result: virtual machine '%s' does not have the tag attached", ref.Value)
+		}
+	}
+
+	for _, c := range []struct{ category, name string }{
+		{"lifecycle", "persistent"},
+		{"missing", "ephemeral"},
+	} {
+		vms, err := sim.driver.FindVMsByTag(c.category, c.name)
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if vms == nil || len(vms) != 0 {
+			t.Fatalf("unexpected result: expected an empty slice for %s:%s, but returned '%v'", c.category, c.name, vms)
+		}
+	}
+}
+
+func TestVCenterDriver_TagsUnsupportedVersion(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	sim.driver.client.ServiceContent.About.Version = "6.0.0"
+	if sim.driver.Version() != "6.0.0" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "6.0.0", sim.driver.Version())
+	}
+
+	_, machine := sim.ChooseSimulatorPreCreatedVM()
+	err = sim.driver.AttachTag(machine.Reference(), "pipeline", "nightly")
+	if err == nil {
+		t.Fatalf("unexpected result: expected an error for an unsupported version")
+	}
+	expected := "tags require vCenter Server 6.5 or later, but the detected version is 6.0.0"
+	if err.Error() != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, err)
+	}
+
+	if _, err := sim.driver.FindVMsByTag("pipeline", "nightly"); err == nil {
+		t.Fatalf("unexpected result: expected an error for an unsupported version")
+	}
+}
+
+func TestVersionAtLeast(t *testing.T) {
+	tc := []struct {
+		version   string
+		supported bool
+		ok        bool
+	}{
+		{version: "6.0.0", supported: false, ok: true},
+		{version: "6.5", supported: true, ok: true},
+		{version: "6.5.0", supported: true, ok: true},
+		{version: "7.0.3", supported: true, ok: true},
+		{version: "10.0", supported: true, ok: true},
+		{version: "6", supported: false, ok: true},
+		{version: "", supported: false, ok: false},
+		{version: "8.0.u3", supported: false, ok: false},
+	}
+
+	for _, c := range tc {
+		supported, ok := versionAtLeast(c.version, minTagsVersion)
+		if supported != c.supported || ok != c.ok {
+			t.Fatalf("unexpected result for '%s': expected (%t, %t), but returned (%t, %t)", c.version, c.supported, c.ok, supported, ok)
+		}
+	}
+}
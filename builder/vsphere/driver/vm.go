@@ -9,6 +9,7 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"path"
 	"reflect"
 	"strconv"
 	"strings"
@@ -94,6 +95,7 @@ type CloneConfig struct {
 	VAppProperties  map[string]string
 	PrimaryDiskSize int64
 	StorageConfig   StorageConfig
+	PowerOn         bool
 }
 
 type PCIPassthroughAllowedDevice struct {
@@ -156,7 +158,7 @@ func (d *VCenterDriver) NewVM(ref *types.ManagedObjectReference) VirtualMachine
 
 // FindVM locates a virtual machine by its name.
 func (d *VCenterDriver) FindVM(name string) (VirtualMachine, error) {
-	vm, err := d.finder.VirtualMachine(d.ctx, name)
+	vm, err := d.finder.VirtualMachine(d.context(), name)
 	if err != nil {
 		return nil, err
 	}
@@ -166,6 +168,200 @@ func (d *VCenterDriver) FindVM(name string) (VirtualMachine, error) {
 	}, nil
 }
 
+// FindVMs returns all virtual machines matching the inventory path glob.
+// Returns an empty slice if no virtual machine matches.
+func (d *VCenterDriver) FindVMs(glob string) ([]VirtualMachine, error) {
+	vms := []VirtualMachine{}
+	list, err := d.finder.VirtualMachineList(d.context(), glob)
+	if err != nil {
+		if _, ok := err.(*find.NotFoundError); ok {
+			return vms, nil
+		}
+		return nil, err
+	}
+
+	for _, vm := range list {
+		vms = append(vms, &VirtualMachineDriver{
+			vm:     vm,
+			driver: d,
+		})
+	}
+	return vms, nil
+}
+
+// FindTemplates returns all templates in the folder and, if recursive is true,
+// in its subfolders. The folder path is relative to the virtual machine folder
+// of the datacenter unless it is absolute. Returns an empty slice if no
+// template is found.
+func (d *VCenterDriver) FindTemplates(folderPath string, recursive bool) ([]VirtualMachine, error) {
+	pattern := "*"
+	if recursive {
+		pattern = "..."
+	}
+
+	templates := []VirtualMachine{}
+	list, err := d.finder.VirtualMachineList(d.context(), path.Join(d.folderInventoryPath(folderPath), pattern))
+	if err != nil {
+		if _, ok := err.(*find.NotFoundError); ok {
+			return templates, nil
+		}
+		return nil, err
+	}
+
+	refs := make([]types.ManagedObjectReference, 0, len(list))
+	byRef := make(map[types.ManagedObjectReference]*object.VirtualMachine, len(list))
+	for _, vm := range list {
+		refs = append(refs, vm.Reference())
+		byRef[vm.Reference()] = vm
+	}
+
+	var props []mo.VirtualMachine
+	if err := property.DefaultCollector(d.vimClient).Retrieve(d.context(), refs, []string{"config.template"}, &props); err != nil {
+		return nil, fmt.Errorf("error retrieving virtual machine properties: %s", err)
+	}
+	for _, p := range props {
+		if p.Config == nil || !p.Config.Template {
+			continue
+		}
+		templates = append(templates, &VirtualMachineDriver{
+			vm:     byRef[p.Reference()],
+			driver: d,
+		})
+	}
+	return templates, nil
+}
+
+// FindVMByUUID locates a virtual machine by its BIOS UUID or, if instanceUUID
+// is true, by its instance UUID.
+func (d *VCenterDriver) FindVMByUUID(uuid string, instanceUUID bool) (VirtualMachine, error) {
+	si := object.NewSearchIndex(d.client.Client)
+	ref, err := si.FindByUuid(d.context(), d.datacenter, uuid, true, &instanceUUID)
+	if err != nil {
+		return nil, err
+	}
+
+	vm, ok := ref.(*object.VirtualMachine)
+	if !ok {
+		kind := "BIOS UUID"
+		if instanceUUID {
+			kind = "instance UUID"
+		}
+		return nil, fmt.Errorf("virtual machine with %s '%s' not found", kind, uuid)
+	}
+	return &VirtualMachineDriver{
+		vm:     vm,
+		driver: d,
+	}, nil
+}
+
+// FindVMByIP locates a virtual machine by the IP address of its guest
+// operating system. The address is only known to vCenter Server when VMware
+// Tools is running in the guest and reports it.
+func (d *VCenterDriver) FindVMByIP(ip string) (VirtualMachine, error) {
+	si := object.NewSearchIndex(d.client.Client)
+	ref, err := si.FindByIp(d.context(), d.datacenter, ip, true)
+	if err != nil {
+		return nil, err
+	}
+
+	vm, ok := ref.(*object.VirtualMachine)
+	if !ok {
+		return nil, fmt.Errorf("virtual machine with IP address '%s' not found", ip)
+	}
+	return &VirtualMachineDriver{
+		vm:     vm,
+		driver: d,
+	}, nil
+}
+
+// GetInventoryPath returns the full inventory path of the virtual machine,
+// such as `/datacenter/vm/folder/name`. The path is resolved from the inventory
+// if the virtual machine was not located by path, such as by UUID or managed
+// object reference.
+func (d *VCenterDriver) GetInventoryPath(vm VirtualMachine) (string, error) {
+	v, ok := vm.(*VirtualMachineDriver)
+	if !ok {
+		return "", fmt.Errorf("unsupported virtual machine type %T", vm)
+	}
+	if v.vm.InventoryPath != "" {
+		return v.vm.InventoryPath, nil
+	}
+
+	p, err := find.InventoryPath(d.context(), d.client.Client, v.vm.Reference())
+	if err != nil {
+		return "", fmt.Errorf("error resolving the inventory path of the virtual machine: %s", err)
+	}
+	v.vm.InventoryPath = p
+	return p, nil
+}
+
+// CloneVM creates a new virtual machine by cloning the source virtual machine
+// and waits for the clone task to complete. The new virtual machine is powered
+// on if requested. Returns the new virtual machine.
+func (d *VCenterDriver) CloneVM(source VirtualMachine, config *CloneConfig) (VirtualMachine, error) {
+	if source == nil {
+		return nil, fmt.Errorf("source virtual machine is required")
+	}
+	if config == nil {
+		return nil, fmt.Errorf("clone configuration is required")
+	}
+
+	vm, err := source.Clone(d.context(), config)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.PowerOn {
+		if err := vm.PowerOn(); err != nil {
+			return vm, fmt.Errorf("error powering on cloned virtual machine: %s", err)
+		}
+	}
+	return vm, nil
+}
+
+// GetPowerState returns the power state of the virtual machine.
+func (d *VCenterDriver) GetPowerState(vm VirtualMachine) (types.VirtualMachinePowerState, error) {
+	if vm == nil {
+		return "", fmt.Errorf("virtual machine is required")
+	}
+
+	info, err := vm.Info("runtime.powerState")
+	if err != nil {
+		return "", fmt.Errorf("error retrieving power state of virtual machine: %s", err)
+	}
+	return info.Runtime.PowerState, nil
+}
+
+// PowerOn powers on the virtual machine and waits for the task to complete.
+// A virtual machine that is already powered on is left unchanged.
+func (d *VCenterDriver) PowerOn(vm VirtualMachine) error {
+	state, err := d.GetPowerState(vm)
+	if err != nil {
+		return err
+	}
+	if state == types.VirtualMachinePowerStatePoweredOn {
+		return nil
+	}
+
+	if err := vm.PowerOn(); err != nil {
+		return fmt.Errorf("error powering on virtual machine: %s", err)
+	}
+	return nil
+}
+
+// PowerOff powers off the virtual machine and waits for the task to complete.
+// A virtual machine that is already powered off is left unchanged.
+func (d *VCenterDriver) PowerOff(vm VirtualMachine) error {
+	if vm == nil {
+		return fmt.Errorf("virtual machine is required")
+	}
+
+	if err := vm.PowerOff(); err != nil {
+		return fmt.Errorf("error powering off virtual machine: %s", err)
+	}
+	return nil
+}
+
 // PreCleanVM checks for an existing virtual machine at the specified path and optionally forces its removal.
 func (d *VCenterDriver) PreCleanVM(ui packersdk.Ui, vmPath string, force bool, vsphereCluster string, vsphereHost string, vsphereResourcePool string) error {
 	vm, err := d.FindVM(vmPath)
@@ -176,32 +372,132 @@ func (d *VCenterDriver) PreCleanVM(ui packersdk.Ui, vmPath string, force bool, v
 	}
 	if force && vm != nil {
 		ui.Sayf("Removing the existing virtual machine at %s based on use of the '-force' option...", vmPath)
+		if err := removeVM(ui, vm, vmPath, vsphereCluster, vsphereHost, vsphereResourcePool); err != nil {
+			return err
+		}
+	}
+	if !force && vm != nil {
+		return fmt.Errorf("%s already exists, you can use -force flag to destroy it: %v", vmPath, err)
+	}
 
-		// Power off the virtual machine if it is powered on.
-		_ = vm.PowerOff()
+	return nil
+}
 
-		// Check if the virtual machine is a template and convert it back to a
-		// virtual machine if necessary.
-		isTemplate, err := vm.IsTemplate()
-		if err != nil {
-			return fmt.Errorf("error determining if the virtual machine is a template%s: %v", vmPath, err)
-		} else if isTemplate {
-			ui.Sayf("Attempting to convert the template at %s to a virtual machine...", vmPath)
-			err := vm.ConvertToVirtualMachine(vsphereCluster, vsphereHost, vsphereResourcePool)
-			if err != nil {
-				return fmt.Errorf("error converting template back to virtual machine for cleanup %s: %v", vmPath, err)
-			}
+// PreCleanVMs checks for existing virtual machines matching the glob pattern
+// and optionally forces their removal. Each virtual machine is removed even if
+// the removal of another fails; the errors are returned together. To avoid
+// removing unrelated virtual machines, the removal is refused unless the name
+// in the pattern starts with a literal prefix, such as `packer-*`.
+func (d *VCenterDriver) PreCleanVMs(ui packersdk.Ui, glob string, force bool, vsphereCluster string, vsphereHost string, vsphereResourcePool string) error {
+	if force && !globHasNamePrefix(glob) {
+		return fmt.Errorf("refusing to remove the virtual machines matching '%s': the name in the pattern must start with a literal prefix, such as 'packer-*'", glob)
+	}
+
+	vms, err := d.FindVMs(glob)
+	if err != nil {
+		return fmt.Errorf("error looking up existing virtual machines: %v", err)
+	}
+	if len(vms) == 0 {
+		return nil
+	}
+
+	var paths []string
+	for _, vm := range vms {
+		paths = append(paths, vm.(*VirtualMachineDriver).vm.InventoryPath)
+	}
+	if !force {
+		return fmt.Errorf("%s already exist, you can use -force flag to destroy them", strings.Join(paths, ", "))
+	}
+
+	var errs *packersdk.MultiError
+	for i, vm := range vms {
+		ui.Sayf("Removing the existing virtual machine at %s (%d of %d) based on use of the '-force' option...", paths[i], i+1, len(vms))
+		if err := removeVM(ui, vm, paths[i], vsphereCluster, vsphereHost, vsphereResourcePool); err != nil {
+			ui.Errorf("Failed to remove the virtual machine at %s: %s", paths[i], err)
+			errs = packersdk.MultiErrorAppend(errs, err)
 		}
+	}
+	if errs != nil {
+		return errs
+	}
+	return nil
+}
+
+// globHasNamePrefix reports whether the last element of the inventory path
+// glob starts with a literal prefix, such as `packer-` in `builds/packer-*`.
+// A recursive `...` element matches any name.
+func globHasNamePrefix(glob string) bool {
+	for _, element := range strings.Split(glob, "/") {
+		if element == "..." {
+			return false
+		}
+	}
+	name := path.Base(glob)
+	return name != "." && name != "/" && strings.IndexAny(name, `*?[\`) != 0
+}
+
+// removeVM powers off and destroys the virtual machine, converting it from a
+// template to a virtual machine first if necessary.
+func removeVM(ui packersdk.Ui, vm VirtualMachine, vmPath string, vsphereCluster string, vsphereHost string, vsphereResourcePool string) error {
+	// Power off the virtual machine if it is powered on.
+	_ = vm.PowerOff()
 
-		err = vm.Destroy()
+	// Check if the virtual machine is a template and convert it back to a
+	// virtual machine if necessary.
+	isTemplate, err := vm.IsTemplate()
+	if err != nil {
+		return fmt.Errorf("error determining if the virtual machine is a template%s: %v", vmPath, err)
+	} else if isTemplate {
+		ui.Sayf("Attempting to convert the template at %s to a virtual machine...", vmPath)
+		err := vm.ConvertToVirtualMachine(vsphereCluster, vsphereHost, vsphereResourcePool)
 		if err != nil {
-			return fmt.Errorf("error destroying %s: %v", vmPath, err)
+			return fmt.Errorf("error converting template back to virtual machine for cleanup %s: %v", vmPath, err)
 		}
 	}
-	if !force && vm != nil {
-		return fmt.Errorf("%s already exists, you can use -force flag to destroy it: %v", vmPath, err)
+
+	err = vm.Destroy()
+	if err != nil {
+		return fmt.Errorf("error destroying %s: %v", vmPath, err)
 	}
+	return nil
+}
 
+// ValidatePlacement resolves the cluster, host, resource pool, and folder used
+// to place a virtual machine. Empty values are not validated. The resource
+// pool is resolved as in CreateVM, including the fallbacks to the default
+// resource pool and to a vApp. Missing folders are accepted, since CreateVM
+// creates them, but the existing part of the folder path must consist of
+// folders. Returns an error listing every target that cannot be resolved.
+func (d *VCenterDriver) ValidatePlacement(cluster, host, resourcePool, folder string) error {
+	var errs *packersdk.MultiError
+	computeFound := true
+
+	if cluster != "" {
+		if _, err := d.FindCluster(cluster); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("cluster '%s' not found: %s", cluster, err))
+			computeFound = false
+		}
+	}
+	if host != "" {
+		if _, err := d.FindHost(host); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("host '%s' not found: %s", host, err))
+			computeFound = computeFound && cluster != ""
+		}
+	}
+	if resourcePool != "" && computeFound {
+		if _, err := d.FindResourcePool(cluster, host, resourcePool); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, err)
+		}
+	}
+	if folder != "" {
+		if err := d.validateFolder(folder); err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("folder '%s' cannot be created: %s", folder, err))
+		}
+	}
+
+	if errs != nil {
+		return errs
+	}
 	return nil
 }
 
@@ -281,11 +577,11 @@ func (d *VCenterDriver) CreateVM(config *CreateConfig) (VirtualMachine, error) {
 		VmPathName: fmt.Sprintf("[%s]", datastore.Name()),
 	}
 
-	task, err := folder.folder.CreateVM(d.ctx, createSpec, resourcePool.pool, host)
+	task, err := folder.folder.CreateVM(d.context(), createSpec, resourcePool.pool, host)
 	if err != nil {
 		return nil, err
 	}
-	taskInfo, err := task.WaitForResult(d.ctx, nil)
+	taskInfo, err := task.WaitForResult(d.context(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -309,7 +605,7 @@ func (vm *VirtualMachineDriver) Info(params ...string) (*mo.VirtualMachine, erro
 		p = params
 	}
 	var info mo.VirtualMachine
-	err := vm.vm.Properties(vm.driver.ctx, vm.vm.Reference(), p, &info)
+	err := vm.vm.Properties(vm.driver.context(), vm.vm.Reference(), p, &info)
 	if err != nil {
 		return nil, err
 	}
@@ -405,7 +701,7 @@ func (vm *VirtualMachineDriver) Clone(ctx context.Context, config *CloneConfig)
 		configSpec.Annotation = config.Annotation
 	}
 
-	devices, err := vm.vm.Device(vm.driver.ctx)
+	devices, err := vm.vm.Device(vm.driver.context())
 	if err != nil {
 		return nil, err
 	}
@@ -474,7 +770,7 @@ func (vm *VirtualMachineDriver) Clone(ctx context.Context, config *CloneConfig)
 	}
 	configSpec.VAppConfig = vAppConfig
 
-	task, err := vm.vm.Clone(vm.driver.ctx, folder.folder, config.Name, cloneSpec)
+	task, err := vm.vm.Clone(vm.driver.context(), folder.folder, config.Name, cloneSpec)
 	if err != nil {
 		return nil, fmt.Errorf("error calling vm.vm.Clone task: %s", err)
 	}
@@ -558,12 +854,12 @@ func (vm *VirtualMachineDriver) AddPublicKeys(ctx context.Context, publicKeys st
 	}
 
 	confSpec := types.VirtualMachineConfigSpec{VAppConfig: config}
-	task, err := vm.vm.Reconfigure(vm.driver.ctx, confSpec)
+	task, err := vm.vm.Reconfigure(vm.driver.context(), confSpec)
 	if err != nil {
 		return err
 	}
 
-	_, err = task.WaitForResult(vm.driver.ctx, nil)
+	_, err = task.WaitForResult(vm.driver.context(), nil)
 	return err
 }
 
@@ -579,11 +875,11 @@ func (vm *VirtualMachineDriver) Properties(ctx context.Context) (*mo.VirtualMach
 
 // Destroy removes the virtual machine.
 func (vm *VirtualMachineDriver) Destroy() error {
-	task, err := vm.vm.Destroy(vm.driver.ctx)
+	task, err := vm.vm.Destroy(vm.driver.context())
 	if err != nil {
 		return err
 	}
-	_, err = task.WaitForResult(vm.driver.ctx, nil)
+	_, err = task.WaitForResult(vm.driver.context(), nil)
 	return err
 }
 
@@ -617,7 +913,7 @@ func (vm *VirtualMachineDriver) Configure(config *HardwareConfig) error {
 	}
 
 	if config.VideoRAM != 0 || config.Displays != 0 {
-		devices, err := vm.vm.Device(vm.driver.ctx)
+		devices, err := vm.vm.Device(vm.driver.context())
 		if err != nil {
 			return err
 		}
@@ -636,7 +932,7 @@ func (vm *VirtualMachineDriver) Configure(config *HardwareConfig) error {
 	}
 
 	if config.VGPUProfile != "" {
-		devices, err := vm.vm.Device(vm.driver.ctx)
+		devices, err := vm.vm.Device(vm.driver.context())
 		if err != nil {
 			return err
 		}
@@ -688,12 +984,12 @@ func (vm *VirtualMachineDriver) Configure(config *HardwareConfig) error {
 		EfiSecureBootEnabled: types.NewBool(efiSecureBootEnabled),
 	}
 
-	task, err := vm.vm.Reconfigure(vm.driver.ctx, confSpec)
+	task, err := vm.vm.Reconfigure(vm.driver.context(), confSpec)
 	if err != nil {
 		return err
 	}
 
-	_, err = task.WaitForResult(vm.driver.ctx, nil)
+	_, err = task.WaitForResult(vm.driver.context(), nil)
 	if err != nil {
 		return err
 	}
@@ -736,22 +1032,22 @@ func (vm *VirtualMachineDriver) Configure(config *HardwareConfig) error {
 // Reconfigure modifies the configuration of an existing virtual machine based
 // on the provided configuration specification.
 func (vm *VirtualMachineDriver) Reconfigure(confSpec types.VirtualMachineConfigSpec) error {
-	task, err := vm.vm.Reconfigure(vm.driver.ctx, confSpec)
+	task, err := vm.vm.Reconfigure(vm.driver.context(), confSpec)
 	if err != nil {
 		return err
 	}
 
-	_, err = task.WaitForResult(vm.driver.ctx, nil)
+	_, err = task.WaitForResult(vm.driver.context(), nil)
 	return err
 }
 
 // Customize applies the given CustomizationSpec to the virtual machine.
 func (vm *VirtualMachineDriver) Customize(spec types.CustomizationSpec) error {
-	task, err := vm.vm.Customize(vm.driver.ctx, spec)
+	task, err := vm.vm.Customize(vm.driver.context(), spec)
 	if err != nil {
 		return err
 	}
-	return task.Wait(vm.driver.ctx)
+	return task.Wait(vm.driver.context())
 }
 
 // ResizeDisk adjusts the size of the virtual disk to the specified diskSize in
@@ -759,7 +1055,7 @@ func (vm *VirtualMachineDriver) Customize(spec types.CustomizationSpec) error {
 // an error if the operation fails.
 // TODO: This method should be refactored to support resizing multiple disks.
 func (vm *VirtualMachineDriver) ResizeDisk(diskSize int64) ([]types.BaseVirtualDeviceConfigSpec, error) {
-	devices, err := vm.vm.Device(vm.driver.ctx)
+	devices, err := vm.vm.Device(vm.driver.context())
 	if err != nil {
 		return nil, err
 	}
@@ -782,11 +1078,11 @@ func (vm *VirtualMachineDriver) ResizeDisk(diskSize int64) ([]types.BaseVirtualD
 
 // PowerOn starts the virtual machine and waits for the operation to complete.
 func (vm *VirtualMachineDriver) PowerOn() error {
-	task, err := vm.vm.PowerOn(vm.driver.ctx)
+	task, err := vm.vm.PowerOn(vm.driver.context())
 	if err != nil {
 		return err
 	}
-	_, err = task.WaitForResult(vm.driver.ctx, nil)
+	_, err = task.WaitForResult(vm.driver.context(), nil)
 	return err
 }
 
@@ -818,7 +1114,7 @@ func (vm *VirtualMachineDriver) WaitForIP(ctx context.Context, ipNet *net.IPNet)
 
 // PowerOff stops the virtual machine and waits for the operation to complete.
 func (vm *VirtualMachineDriver) PowerOff() error {
-	state, err := vm.vm.PowerState(vm.driver.ctx)
+	state, err := vm.vm.PowerState(vm.driver.context())
 	if err != nil {
 		return err
 	}
@@ -827,17 +1123,17 @@ func (vm *VirtualMachineDriver) PowerOff() error {
 		return nil
 	}
 
-	task, err := vm.vm.PowerOff(vm.driver.ctx)
+	task, err := vm.vm.PowerOff(vm.driver.context())
 	if err != nil {
 		return err
 	}
-	_, err = task.WaitForResult(vm.driver.ctx, nil)
+	_, err = task.WaitForResult(vm.driver.context(), nil)
 	return err
 }
 
 // IsPoweredOff checks if the virtual machine is powered off.
 func (vm *VirtualMachineDriver) IsPoweredOff() (bool, error) {
-	state, err := vm.vm.PowerState(vm.driver.ctx)
+	state, err := vm.vm.PowerState(vm.driver.context())
 	if err != nil {
 		return false, err
 	}
@@ -847,7 +1143,7 @@ func (vm *VirtualMachineDriver) IsPoweredOff() (bool, error) {
 
 // StartShutdown initiates a guest shutdown operation.
 func (vm *VirtualMachineDriver) StartShutdown() error {
-	err := vm.vm.ShutdownGuest(vm.driver.ctx)
+	err := vm.vm.ShutdownGuest(vm.driver.context())
 	return err
 }
 
@@ -878,22 +1174,22 @@ func (vm *VirtualMachineDriver) WaitForShutdown(ctx context.Context, timeout tim
 
 // CreateSnapshot creates a snapshot of the virtual machine.
 func (vm *VirtualMachineDriver) CreateSnapshot(name string) error {
-	task, err := vm.vm.CreateSnapshot(vm.driver.ctx, name, "", false, false)
+	task, err := vm.vm.CreateSnapshot(vm.driver.context(), name, "", false, false)
 	if err != nil {
 		return err
 	}
-	_, err = task.WaitForResult(vm.driver.ctx, nil)
+	_, err = task.WaitForResult(vm.driver.context(), nil)
 	return err
 }
 
 // ConvertToTemplate converts the virtual machine to a template.
 func (vm *VirtualMachineDriver) ConvertToTemplate() error {
-	return vm.vm.MarkAsTemplate(vm.driver.ctx)
+	return vm.vm.MarkAsTemplate(vm.driver.context())
 }
 
 // IsTemplate checks if the virtual machine is a template.
 func (vm *VirtualMachineDriver) IsTemplate() (bool, error) {
-	state, err := vm.vm.IsTemplate(vm.driver.ctx)
+	state, err := vm.vm.IsTemplate(vm.driver.context())
 	if err != nil {
 		return false, err
 	}
@@ -917,12 +1213,12 @@ func (vm *VirtualMachineDriver) ConvertToVirtualMachine(vsphereCluster string, v
 		return err
 	}
 
-	return vm.vm.MarkAsVirtualMachine(vm.driver.ctx, *resourcePool.pool, host)
+	return vm.vm.MarkAsVirtualMachine(vm.driver.context(), *resourcePool.pool, host)
 }
 
 // ImportOvfToContentLibrary imports the OVF to the content library.
 func (vm *VirtualMachineDriver) ImportOvfToContentLibrary(ovf vcenter.OVF) error {
-	err := vm.driver.restClient.Login(vm.driver.ctx)
+	err := vm.driver.restClient.Login(vm.driver.context())
 	if err != nil {
 		return err
 	}
@@ -957,17 +1253,17 @@ func (vm *VirtualMachineDriver) ImportOvfToContentLibrary(ovf vcenter.OVF) error
 	ovf.Source.Type = "VirtualMachine"
 
 	vcm := vcenter.NewManager(vm.driver.restClient.client)
-	_, err = vcm.CreateOVF(vm.driver.ctx, ovf)
+	_, err = vcm.CreateOVF(vm.driver.context(), ovf)
 	if err != nil {
 		return err
 	}
 
-	return vm.driver.restClient.Logout(vm.driver.ctx)
+	return vm.driver.restClient.Logout(vm.driver.context())
 }
 
 // ImportToContentLibrary imports the virtual machine to the content library.
 func (vm *VirtualMachineDriver) ImportToContentLibrary(template vcenter.Template) error {
-	err := vm.driver.restClient.Login(vm.driver.ctx)
+	err := vm.driver.restClient.Login(vm.driver.context())
 	if err != nil {
 		return err
 	}
@@ -1032,14 +1328,14 @@ func (vm *VirtualMachineDriver) ImportToContentLibrary(template vcenter.Template
 	}
 
 	vcm := vcenter.NewManager(vm.driver.restClient.client)
-	_, err = vcm.CreateTemplate(vm.driver.ctx, template)
+	_, err = vcm.CreateTemplate(vm.driver.context(), template)
 	if err != nil {
 		log.Printf("cannot create template: %v", err)
 		vm.logout()
 		return err
 	}
 
-	return vm.driver.restClient.Logout(vm.driver.ctx)
+	return vm.driver.restClient.Logout(vm.driver.context())
 }
 
 // GetDir returns the directory of the virtual machine. Returns an error if the
@@ -1068,7 +1364,7 @@ func addNetwork(d *VCenterDriver, devices object.VirtualDeviceList, config *Crea
 			return nil, err
 		}
 
-		backing, err := network.EthernetCardBackingInfo(d.ctx)
+		backing, err := network.EthernetCardBackingInfo(d.context())
 		if err != nil {
 			return nil, err
 		}
@@ -1212,7 +1508,7 @@ func newVGPUProfile(vGPUProfile string) types.VirtualPCIPassthrough {
 // mountCdrom mounts a CD-ROM to the virtual machine.
 func (vm *VirtualMachineDriver) MountCdrom(controllerType string, datastoreIsoPath string, _cdrom types.BaseVirtualDevice) error {
 	cdrom := _cdrom.(*types.VirtualCdrom)
-	devices, err := vm.vm.Device(vm.driver.ctx)
+	devices, err := vm.vm.Device(vm.driver.context())
 	if err != nil {
 		return err
 	}
@@ -1238,7 +1534,7 @@ func (vm *VirtualMachineDriver) MountCdrom(controllerType string, datastoreIsoPa
 
 // AddCdrom adds a CD-ROM to the virtual machine.
 func (vm *VirtualMachineDriver) AddCdrom(controllerType string, datastoreIsoPath string) error {
-	devices, err := vm.vm.Device(vm.driver.ctx)
+	devices, err := vm.vm.Device(vm.driver.context())
 	if err != nil {
 		return err
 	}
@@ -1279,7 +1575,7 @@ func (vm *VirtualMachineDriver) AddCdrom(controllerType string, datastoreIsoPath
 
 // AddFloppy adds a floppy disk to the virtual machine.
 func (vm *VirtualMachineDriver) AddFloppy(imgPath string) error {
-	devices, err := vm.vm.Device(vm.driver.ctx)
+	devices, err := vm.vm.Device(vm.driver.context())
 	if err != nil {
 		return err
 	}
@@ -1298,7 +1594,7 @@ func (vm *VirtualMachineDriver) AddFloppy(imgPath string) error {
 
 // SetBootOrder sets the boot order of the virtual machine.
 func (vm *VirtualMachineDriver) SetBootOrder(order []string) error {
-	devices, err := vm.vm.Device(vm.driver.ctx)
+	devices, err := vm.vm.Device(vm.driver.context())
 	if err != nil {
 		return err
 	}
@@ -1307,12 +1603,12 @@ func (vm *VirtualMachineDriver) SetBootOrder(order []string) error {
 		BootOrder: devices.BootOrder(order),
 	}
 
-	return vm.vm.SetBootOptions(vm.driver.ctx, &bootOptions)
+	return vm.vm.SetBootOptions(vm.driver.context(), &bootOptions)
 }
 
 // RemoveDevice removes a device from the virtual machine.
 func (vm *VirtualMachineDriver) RemoveDevice(keepFiles bool, device ...types.BaseVirtualDevice) error {
-	return vm.vm.RemoveDevice(vm.driver.ctx, keepFiles, device...)
+	return vm.vm.RemoveDevice(vm.driver.context(), keepFiles, device...)
 }
 
 // addDevice adds a device to the virtual machine.
@@ -1325,12 +1621,12 @@ func (vm *VirtualMachineDriver) addDevice(device types.BaseVirtualDevice) error
 		return err
 	}
 
-	task, err := vm.vm.Reconfigure(vm.driver.ctx, confSpec)
+	task, err := vm.vm.Reconfigure(vm.driver.context(), confSpec)
 	if err != nil {
 		return err
 	}
 
-	_, err = task.WaitForResult(vm.driver.ctx, nil)
+	_, err = task.WaitForResult(vm.driver.context(), nil)
 	return err
 }
 
@@ -1351,12 +1647,12 @@ func (vm *VirtualMachineDriver) AddConfigParams(params map[string]string, info *
 	confSpec.Tools = info
 
 	if len(confSpec.ExtraConfig) > 0 || confSpec.Tools != nil {
-		task, err := vm.vm.Reconfigure(vm.driver.ctx, confSpec)
+		task, err := vm.vm.Reconfigure(vm.driver.context(), confSpec)
 		if err != nil {
 			return fmt.Errorf("failed to start reconfiguration task: %w", err)
 		}
 
-		_, err = task.WaitForResult(vm.driver.ctx, nil)
+		_, err = task.WaitForResult(vm.driver.context(), nil)
 		if err != nil {
 			return fmt.Errorf("reconfiguration task failed: %w", err)
 		}
@@ -1365,7 +1661,7 @@ func (vm *VirtualMachineDriver) AddConfigParams(params map[string]string, info *
 
 		// Retrieve the current configuration.
 		var moVM mo.VirtualMachine
-		err = vm.vm.Properties(vm.driver.ctx, vm.vm.Reference(), []string{"config.extraConfig"}, &moVM)
+		err = vm.vm.Properties(vm.driver.context(), vm.vm.Reference(), []string{"config.extraConfig"}, &moVM)
 		if err != nil {
 			return fmt.Errorf("failed to retrieve current configuration: %w", err)
 		}
@@ -1415,12 +1711,12 @@ func (vm *VirtualMachineDriver) AddFlag(ctx context.Context, flagSpec *types.Vir
 
 // Export exports the virtual machine.
 func (vm *VirtualMachineDriver) Export() (*nfc.Lease, error) {
-	return vm.vm.Export(vm.driver.ctx)
+	return vm.vm.Export(vm.driver.context())
 }
 
 // CreateDescriptor creates a descriptor for the virtual machine used when exporting the virtual machine to an OVF.
 func (vm *VirtualMachineDriver) CreateDescriptor(m *ovf.Manager, cdp types.OvfCreateDescriptorParams) (*types.OvfCreateDescriptorResult, error) {
-	return m.CreateDescriptor(vm.driver.ctx, vm.vm, cdp)
+	return m.CreateDescriptor(vm.driver.context(), vm.vm, cdp)
 }
 
 // NewOvfManager creates a new OVF manager instance.
@@ -1431,7 +1727,7 @@ func (vm *VirtualMachineDriver) NewOvfManager() *ovf.Manager {
 // GetOvfExportOptions retrieves the OVF export options for the virtual machine.
 func (vm *VirtualMachineDriver) GetOvfExportOptions(m *ovf.Manager) ([]types.OvfOptionInfo, error) {
 	var mgr mo.OvfManager
-	err := property.DefaultCollector(vm.vm.Client()).RetrieveOne(vm.driver.ctx, m.Reference(), nil, &mgr)
+	err := property.DefaultCollector(vm.vm.Client()).RetrieveOne(vm.driver.context(), m.Reference(), nil, &mgr)
 	if err != nil {
 		return nil, err
 	}
@@ -1465,7 +1761,7 @@ func (vm *VirtualMachineDriver) Datacenter() *object.Datacenter {
 
 // FindContentLibraryItemUUID finds a content library item by name.
 func (vm *VirtualMachineDriver) FindContentLibraryItemUUID(library string, name string) (string, error) {
-	err := vm.driver.restClient.Login(vm.driver.ctx)
+	err := vm.driver.restClient.Login(vm.driver.context())
 	if err != nil {
 		return "", err
 	}
@@ -1489,7 +1785,7 @@ func (vm *VirtualMachineDriver) FindContentLibraryItemUUID(library string, name
 
 // FindContentLibraryTemplateDatastoreName finds the datastore name of the content library template.
 func (vm *VirtualMachineDriver) FindContentLibraryTemplateDatastoreName(library string) ([]string, error) {
-	err := vm.driver.restClient.Login(vm.driver.ctx)
+	err := vm.driver.restClient.Login(vm.driver.context())
 	if err != nil {
 		return nil, err
 	}
@@ -1509,7 +1805,7 @@ func (vm *VirtualMachineDriver) FindContentLibraryTemplateDatastoreName(library
 		}
 		datastores = append(datastores, name)
 	}
-	return datastores, vm.driver.restClient.Logout(vm.driver.ctx)
+	return datastores, vm.driver.restClient.Logout(vm.driver.context())
 }
 
 // logout logs the user out of the vCenter.
@@ -1517,7 +1813,7 @@ func (vm *VirtualMachineDriver) logout() {
 	if vm.driver.restClient == nil {
 		return
 	}
-	if err := vm.driver.restClient.Logout(vm.driver.ctx); err != nil {
+	if err := vm.driver.restClient.Logout(vm.driver.context()); err != nil {
 		log.Printf("cannot logout: %s ", err)
 	}
 }
@@ -0,0 +1,310 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"archive/tar"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/vmware/govmomi/vim25/progress"
+	"github.com/vmware/govmomi/vim25/soap"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// ExportOptions configures the export of a virtual machine to Open
+// Virtualization Format (OVF).
+type ExportOptions struct {
+	// The base name of the exported files.
+	Name string
+	// Include additional image files, such as `.nvram` and `.log` files.
+	ImageFiles bool
+	// The hash algorithm used for the manifest file: 'none', 'sha1', 'sha256'
+	// or 'sha512'. Defaults to 'sha256'.
+	Manifest string
+	// Advanced export options passed to the OVF descriptor, such as 'mac' or
+	// 'uuid'. Options unknown to the virtual machine are reported, but still
+	// passed.
+	Options []string
+	// Package the export as a single Open Virtualization Archive (OVA)
+	// instead of a folder of files.
+	Ova bool
+	// The UI used to report progress. Progress is not reported if nil.
+	Ui packersdk.Ui
+}
+
+// Supported manifest hash algorithms.
+var exportManifestHash = map[string]func() hash.Hash{
+	"none":   nil,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+}
+
+// ExportToOVF exports the virtual machine to the target directory using an
+// NFC lease and returns the path to the resulting `.ovf` or `.ova` file.
+func (d *VCenterDriver) ExportToOVF(vm VirtualMachine, targetDir string, opts ExportOptions) (string, error) {
+	names, err := d.exportOVFFiles(vm, targetDir, opts)
+	if err != nil {
+		return "", err
+	}
+	if !opts.Ova {
+		return filepath.Join(targetDir, names[0]), nil
+	}
+
+	say := func(format string, args ...interface{}) {
+		if opts.Ui != nil {
+			opts.Ui.Sayf(format, args...)
+		}
+	}
+
+	ovaPath := filepath.Join(targetDir, opts.Name+".ova")
+	say("Writing Open Virtualization Archive (OVA) %s...", opts.Name+".ova")
+	if err := writeOva(ovaPath, targetDir, names); err != nil {
+		return "", fmt.Errorf("error writing ova: %s", err)
+	}
+
+	for _, name := range names {
+		if err := os.Remove(filepath.Join(targetDir, name)); err != nil {
+			say("Unable to remove file %s: %s", name, err)
+		}
+	}
+	return ovaPath, nil
+}
+
+// exportOVFFiles exports the virtual machine to the target directory in Open
+// Virtualization Format (OVF) using an NFC lease. Returns the names of the
+// files written in the order required by an Open Virtualization Archive
+// (OVA): the descriptor, the manifest, if any, and the downloaded files. The
+// Ova option is ignored.
+func (d *VCenterDriver) exportOVFFiles(vm VirtualMachine, targetDir string, opts ExportOptions) ([]string, error) {
+	if vm == nil {
+		return nil, fmt.Errorf("virtual machine is required")
+	}
+	if opts.Name == "" {
+		return nil, fmt.Errorf("export name is required")
+	}
+	if opts.Manifest == "" {
+		opts.Manifest = "sha256"
+	}
+	newHash, ok := exportManifestHash[opts.Manifest]
+	if !ok {
+		return nil, fmt.Errorf("unsupported manifest hash: %s", opts.Manifest)
+	}
+
+	if err := os.MkdirAll(targetDir, 0750); err != nil {
+		return nil, fmt.Errorf("error creating export directory: %s", err)
+	}
+
+	say := func(format string, args ...interface{}) {
+		if opts.Ui != nil {
+			opts.Ui.Sayf(format, args...)
+		}
+	}
+
+	lease, err := vm.Export()
+	if err != nil {
+		return nil, fmt.Errorf("error exporting virtual machine: %s", err)
+	}
+
+	info, err := lease.Wait(d.context(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("error waiting for export lease: %s", err)
+	}
+
+	u := lease.StartUpdater(d.context(), info)
+	defer u.Done()
+
+	cdp := types.OvfCreateDescriptorParams{
+		Name: opts.Name,
+	}
+
+	m := vm.NewOvfManager()
+	if len(opts.Options) > 0 {
+		exportOptions, err := vm.GetOvfExportOptions(m)
+		if err != nil {
+			return nil, fmt.Errorf("error retrieving export options: %s", err)
+		}
+		var unknown []string
+		for _, option := range opts.Options {
+			found := false
+			for _, exportOption := range exportOptions {
+				if exportOption.Option == option {
+					found = true
+					break
+				}
+			}
+			if !found {
+				unknown = append(unknown, option)
+			}
+			cdp.ExportOption = append(cdp.ExportOption, option)
+		}
+		if len(unknown) > 0 {
+			log.Printf("[WARN] Unknown export options: %s", strings.Join(unknown, ","))
+			if opts.Ui != nil {
+				opts.Ui.Errorf("unknown export options %s", strings.Join(unknown, ","))
+			}
+		}
+	}
+
+	var mf bytes.Buffer
+	addHash := func(name string, h hash.Hash) {
+		_, _ = fmt.Fprintf(&mf, "%s(%s)= %x\n", strings.ToUpper(opts.Manifest), name, h.Sum(nil))
+	}
+
+	var files []string
+	for _, item := range info.Items {
+		if !opts.ImageFiles && filepath.Ext(item.Path) != ".vmdk" {
+			continue
+		}
+		if !strings.HasPrefix(item.Path, opts.Name) {
+			item.Path = opts.Name + "-" + item.Path
+		}
+
+		file := item.File()
+		say("Downloading %s...", file.Path)
+
+		path := filepath.Join(targetDir, item.Path)
+		download := soap.Download{}
+		var h hash.Hash
+		if newHash != nil {
+			h = newHash()
+			download.Writer = h
+		}
+
+		var wg sync.WaitGroup
+		download.Progress = item
+		if opts.Ui != nil {
+			download.Progress = progress.Tee(item, uiProgress(opts.Ui, file.Path, &wg))
+		}
+
+		err := lease.DownloadFile(d.context(), path, item, download)
+		wg.Wait()
+		if err != nil {
+			return nil, fmt.Errorf("error downloading %s: %s", file.Path, err)
+		}
+		if h != nil {
+			addHash(item.Path, h)
+		}
+
+		stat, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		file.Size = stat.Size()
+		cdp.OvfFiles = append(cdp.OvfFiles, file)
+		files = append(files, item.Path)
+	}
+
+	if err := lease.Complete(d.context()); err != nil {
+		return nil, fmt.Errorf("error completing export lease: %s", err)
+	}
+
+	desc, err := vm.CreateDescriptor(m, cdp)
+	if err != nil {
+		return nil, fmt.Errorf("error creating descriptor: %s", err)
+	}
+
+	ovfName := opts.Name + ".ovf"
+	say("Writing OVF descriptor %s...", ovfName)
+	if err := os.WriteFile(filepath.Join(targetDir, ovfName), []byte(desc.OvfDescriptor), 0644); err != nil {
+		return nil, fmt.Errorf("error writing descriptor: %s", err)
+	}
+
+	names := []string{ovfName}
+	if newHash != nil {
+		h := newHash()
+		_, _ = io.WriteString(h, desc.OvfDescriptor)
+		addHash(ovfName, h)
+
+		mfName := opts.Name + ".mf"
+		say("Writing %s manifest %s...", strings.ToUpper(opts.Manifest), mfName)
+		if err := os.WriteFile(filepath.Join(targetDir, mfName), mf.Bytes(), 0644); err != nil {
+			return nil, fmt.Errorf("error writing manifest: %s", err)
+		}
+		names = append(names, mfName)
+	}
+	return append(names, files...), nil
+}
+
+// writeOva packages the named files from dir into a tar archive at path. The
+// descriptor must be the first file in the archive, followed by the manifest.
+func writeOva(path string, dir string, names []string) error {
+	ova, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(ova)
+	for _, name := range names {
+		if err := addToTar(tw, filepath.Join(dir, name), name); err != nil {
+			_ = ova.Close()
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		_ = ova.Close()
+		return err
+	}
+	return ova.Close()
+}
+
+func addToTar(tw *tar.Writer, path string, name string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	stat, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	header, err := tar.FileInfoHeader(stat, "")
+	if err != nil {
+		return err
+	}
+	header.Name = name
+
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// uiProgress returns a progress sink that reports the download progress
+// of a file through the UI in 25 percent increments.
+func uiProgress(ui packersdk.Ui, name string, wg *sync.WaitGroup) progress.Sinker {
+	return progress.SinkFunc(func() chan<- progress.Report {
+		ch := make(chan progress.Report)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			next := float32(25)
+			for r := range ch {
+				if r.Percentage() >= next && next < 100 {
+					ui.Sayf("Downloading %s: %.0f%%", name, r.Percentage())
+					for next <= r.Percentage() {
+						next += 25
+					}
+				}
+			}
+		}()
+		return ch
+	})
+}
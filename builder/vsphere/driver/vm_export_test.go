@@ -0,0 +1,216 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package driver
+
+import (
+	"archive/tar"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/vmware/govmomi/nfc"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/vim25/methods"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+// newSimulatorExportLease returns an export lease for a pre-created virtual
+// machine. The simulator does not implement ExportVm, so the lease is created
+// by exporting a snapshot of the virtual machine instead.
+func newSimulatorExportLease(t *testing.T, sim *VCenterSimulator) *nfc.Lease {
+	_, machine := sim.ChooseSimulatorPreCreatedVM()
+	vm := object.NewVirtualMachine(sim.driver.vimClient, machine.Reference())
+
+	task, err := vm.CreateSnapshot(sim.driver.ctx, "export", "", false, false)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	info, err := task.WaitForResult(sim.driver.ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	res, err := methods.ExportSnapshot(sim.driver.ctx, sim.driver.vimClient, &types.ExportSnapshot{
+		This: info.Result.(types.ManagedObjectReference),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	return nfc.NewLease(sim.driver.vimClient, res.Returnval)
+}
+
+func TestVCenterDriver_ExportToOVF(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	tc := []struct {
+		name     string
+		opts     ExportOptions
+		expected string
+		files    []string
+	}{
+		{
+			name:     "ovf with manifest",
+			opts:     ExportOptions{Name: "export"},
+			expected: "export.ovf",
+			files:    []string{"export.ovf", "export.mf", "export-disk1.vmdk"},
+		},
+		{
+			name:     "ovf without manifest",
+			opts:     ExportOptions{Name: "export", Manifest: "none"},
+			expected: "export.ovf",
+			files:    []string{"export.ovf", "export-disk1.vmdk"},
+		},
+		{
+			name:     "ova",
+			opts:     ExportOptions{Name: "export", Ova: true, Ui: packersdk.TestUi(t)},
+			expected: "export.ova",
+			files:    []string{"export.ova"},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			vm := &VirtualMachineMock{
+				ExportLease: newSimulatorExportLease(t, sim),
+				CreateDescriptorResult: &types.OvfCreateDescriptorResult{
+					OvfDescriptor: "<Envelope/>",
+				},
+			}
+
+			target, err := sim.driver.ExportToOVF(vm, dir, c.opts)
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if target != filepath.Join(dir, c.expected) {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", filepath.Join(dir, c.expected), target)
+			}
+			if !vm.CreateDescriptorCalled {
+				t.Fatalf("unexpected result: expected the descriptor to be created")
+			}
+			if len(vm.CreateDescriptorParams.OvfFiles) != 1 {
+				t.Fatalf("unexpected result: expected 1 file in the descriptor, but returned %d", len(vm.CreateDescriptorParams.OvfFiles))
+			}
+
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if len(entries) != len(c.files) {
+				t.Fatalf("unexpected result: expected %d files, but returned %d", len(c.files), len(entries))
+			}
+			for _, file := range c.files {
+				if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+					t.Fatalf("unexpected error: '%s'", err)
+				}
+			}
+		})
+	}
+}
+
+func TestVCenterDriver_ExportToOVF_OvaLayout(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm := &VirtualMachineMock{
+		ExportLease: newSimulatorExportLease(t, sim),
+		CreateDescriptorResult: &types.OvfCreateDescriptorResult{
+			OvfDescriptor: "<Envelope/>",
+		},
+	}
+
+	target, err := sim.driver.ExportToOVF(vm, t.TempDir(), ExportOptions{Name: "export", Ova: true})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	f, err := os.Open(target)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer f.Close()
+
+	var names []string
+	tr := tar.NewReader(f)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		names = append(names, header.Name)
+	}
+
+	expected := []string{"export.ovf", "export.mf", "export-disk1.vmdk"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("unexpected result: expected '%v', but returned '%v'", expected, names)
+	}
+}
+
+func TestVCenterDriver_ExportOVFFiles(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm := &VirtualMachineMock{
+		ExportLease: newSimulatorExportLease(t, sim),
+		CreateDescriptorResult: &types.OvfCreateDescriptorResult{
+			OvfDescriptor: "<Envelope/>",
+		},
+	}
+
+	// Unknown export options are passed to the descriptor.
+	names, err := sim.driver.exportOVFFiles(vm, t.TempDir(), ExportOptions{
+		Name:    "export",
+		Options: []string{"mac"},
+		Ova:     true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	expected := []string{"export.ovf", "export.mf", "export-disk1.vmdk"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("unexpected result: expected '%v', but returned '%v'", expected, names)
+	}
+	if !reflect.DeepEqual(vm.CreateDescriptorParams.ExportOption, []string{"mac"}) {
+		t.Fatalf("unexpected result: expected '%v', but returned '%v'", []string{"mac"}, vm.CreateDescriptorParams.ExportOption)
+	}
+}
+
+func TestVCenterDriver_ExportToOVF_Validation(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	if _, err := sim.driver.ExportToOVF(nil, t.TempDir(), ExportOptions{Name: "export"}); err == nil {
+		t.Fatalf("unexpected result: expected an error for a missing virtual machine")
+	}
+
+	vm := &VirtualMachineMock{}
+	if _, err := sim.driver.ExportToOVF(vm, t.TempDir(), ExportOptions{}); err == nil {
+		t.Fatalf("unexpected result: expected an error for a missing name")
+	}
+	if _, err := sim.driver.ExportToOVF(vm, t.TempDir(), ExportOptions{Name: "export", Manifest: "md5"}); err == nil {
+		t.Fatalf("unexpected result: expected an error for an unsupported manifest")
+	}
+	if vm.ExportCalled {
+		t.Fatalf("unexpected result: expected the export not to start")
+	}
+}
@@ -41,6 +41,15 @@ type VirtualMachineMock struct {
 	AddFlagVbsEnabledValues  bool
 	AddFlagVvtdEnabledValues bool
 
+	ExportCalled bool
+	ExportLease  *nfc.Lease
+	ExportErr    error
+
+	CreateDescriptorCalled bool
+	CreateDescriptorParams types.OvfCreateDescriptorParams
+	CreateDescriptorResult *types.OvfCreateDescriptorResult
+	CreateDescriptorErr    error
+
 	GetDirCalled   bool
 	GetDirResponse string
 	GetDirErr      error
@@ -249,11 +258,14 @@ func (vm *VirtualMachineMock) AddFlag(ctx context.Context, info *types.VirtualMa
 }
 
 func (vm *VirtualMachineMock) Export() (*nfc.Lease, error) {
-	return nil, nil
+	vm.ExportCalled = true
+	return vm.ExportLease, vm.ExportErr
 }
 
 func (vm *VirtualMachineMock) CreateDescriptor(m *ovf.Manager, cdp types.OvfCreateDescriptorParams) (*types.OvfCreateDescriptorResult, error) {
-	return nil, nil
+	vm.CreateDescriptorCalled = true
+	vm.CreateDescriptorParams = cdp
+	return vm.CreateDescriptorResult, vm.CreateDescriptorErr
 }
 
 func (vm *VirtualMachineMock) NewOvfManager() *ovf.Manager {
@@ -5,8 +5,12 @@ package driver
 
 import (
 	"context"
+	"strings"
 	"testing"
 
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/vmware/govmomi/object"
+	"github.com/vmware/govmomi/simulator"
 	"github.com/vmware/govmomi/vim25/types"
 )
 
@@ -221,3 +225,589 @@ func TestVirtualMachineDriver_CloneWithMacAddress(t *testing.T) {
 		t.Fatalf("unexpected result: expected '%s', but returned '%s'", newMacAddress, network.MacAddress)
 	}
 }
+
+func TestVCenterDriver_CloneVM(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, datastore := sim.ChooseSimulatorPreCreatedDatastore()
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+
+	config := &CloneConfig{
+		Name:      "mock name",
+		Host:      "DC0_H0",
+		Datastore: datastore.Name,
+		PowerOn:   true,
+	}
+
+	clonedVM, err := sim.driver.CloneVM(vm, config)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	found, err := sim.driver.FindVM(config.Name)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	info, err := found.Info("runtime.powerState")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if info.Runtime.PowerState != types.VirtualMachinePowerStatePoweredOn {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", types.VirtualMachinePowerStatePoweredOn, info.Runtime.PowerState)
+	}
+
+	clonedInfo, err := clonedVM.Info("name")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if clonedInfo.Name != config.Name {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", config.Name, clonedInfo.Name)
+	}
+
+	if _, err = sim.driver.CloneVM(nil, config); err == nil {
+		t.Fatalf("unexpected result: expected an error for a missing source virtual machine")
+	}
+	if _, err = sim.driver.CloneVM(found, nil); err == nil {
+		t.Fatalf("unexpected result: expected an error for a missing clone configuration")
+	}
+}
+
+func TestVCenterDriver_FindVMByUUID(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, machine := sim.ChooseSimulatorPreCreatedVM()
+
+	tc := []struct {
+		name         string
+		uuid         string
+		instanceUUID bool
+		valid        bool
+	}{
+		{
+			name:  "BIOS UUID",
+			uuid:  machine.Config.Uuid,
+			valid: true,
+		},
+		{
+			name:         "instance UUID",
+			uuid:         machine.Config.InstanceUuid,
+			instanceUUID: true,
+			valid:        true,
+		},
+		{
+			name:         "BIOS UUID as instance UUID",
+			uuid:         machine.Config.Uuid,
+			instanceUUID: true,
+			valid:        false,
+		},
+		{
+			name:  "unknown UUID",
+			uuid:  "00000000-0000-0000-0000-000000000000",
+			valid: false,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			vm, err := sim.driver.FindVMByUUID(c.uuid, c.instanceUUID)
+			if !c.valid {
+				if err == nil {
+					t.Fatalf("unexpected result: expected an error for '%s'", c.uuid)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			info, err := vm.Info("name")
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if info.Name != machine.Name {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", machine.Name, info.Name)
+			}
+		})
+	}
+}
+
+func TestVCenterDriver_FindVMByIP(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, machine := sim.ChooseSimulatorPreCreatedVM()
+	machine.Guest.IpAddress = "10.0.0.10"
+
+	vm, err := sim.driver.FindVMByIP("10.0.0.10")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	info, err := vm.Info("name")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if info.Name != machine.Name {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", machine.Name, info.Name)
+	}
+
+	if _, err := sim.driver.FindVMByIP("10.0.0.11"); err == nil {
+		t.Fatalf("unexpected result: expected an error for an unknown IP address")
+	}
+}
+
+func TestVCenterDriver_PowerState(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	vm, _ := sim.ChooseSimulatorPreCreatedVM()
+
+	tc := []struct {
+		name     string
+		toggle   func(VirtualMachine) error
+		expected types.VirtualMachinePowerState
+	}{
+		{
+			name:     "power off",
+			toggle:   sim.driver.PowerOff,
+			expected: types.VirtualMachinePowerStatePoweredOff,
+		},
+		{
+			name:     "power off when powered off",
+			toggle:   sim.driver.PowerOff,
+			expected: types.VirtualMachinePowerStatePoweredOff,
+		},
+		{
+			name:     "power on",
+			toggle:   sim.driver.PowerOn,
+			expected: types.VirtualMachinePowerStatePoweredOn,
+		},
+		{
+			name:     "power on when powered on",
+			toggle:   sim.driver.PowerOn,
+			expected: types.VirtualMachinePowerStatePoweredOn,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			if err := c.toggle(vm); err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			state, err := sim.driver.GetPowerState(vm)
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if state != c.expected {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expected, state)
+			}
+		})
+	}
+
+	if _, err := sim.driver.GetPowerState(nil); err == nil {
+		t.Fatalf("unexpected result: expected an error for a missing virtual machine")
+	}
+}
+
+func TestVCenterDriver_FindVMs(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, machine := sim.ChooseSimulatorPreCreatedVM()
+	all := sim.model.Map().All("VirtualMachine")
+	if len(all) < 2 {
+		t.Fatalf("unexpected result: expected at least 2 virtual machines, but returned %d", len(all))
+	}
+
+	tc := []struct {
+		name     string
+		glob     string
+		expected int
+	}{
+		{
+			name:     "no matches",
+			glob:     "missing*",
+			expected: 0,
+		},
+		{
+			name:     "one match",
+			glob:     machine.Name,
+			expected: 1,
+		},
+		{
+			name:     "many matches",
+			glob:     "*",
+			expected: len(all),
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			vms, err := sim.driver.FindVMs(c.glob)
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if vms == nil || len(vms) != c.expected {
+				t.Fatalf("unexpected result: expected %d virtual machines, but returned %d", c.expected, len(vms))
+			}
+		})
+	}
+}
+
+func TestVCenterDriver_FindTemplates(t *testing.T) {
+	model := simulator.VPX()
+	model.Machine = 3
+	sim, err := NewCustomVCenterSimulator(model)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	all, err := sim.driver.FindVMs("*")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	folder, err := sim.driver.CreateFolder("", "templates")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// One template in the datacenter folder, one in a subfolder, and one
+	// virtual machine that is not a template.
+	for _, vm := range all[:2] {
+		if err := vm.PowerOff(); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if err := vm.ConvertToTemplate(); err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+	moved := all[1].(*VirtualMachineDriver).vm
+	task, err := folder.folder.MoveInto(context.TODO(), []types.ManagedObjectReference{moved.Reference()})
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if err := task.Wait(context.TODO()); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	tc := []struct {
+		name      string
+		folder    string
+		recursive bool
+		expected  int
+	}{
+		{
+			name:      "datacenter folder",
+			folder:    "",
+			recursive: true,
+			expected:  2,
+		},
+		{
+			name:     "datacenter folder without subfolders",
+			folder:   "",
+			expected: 1,
+		},
+		{
+			name:      "subfolder",
+			folder:    "templates",
+			recursive: true,
+			expected:  1,
+		},
+		{
+			name:      "missing folder",
+			folder:    "missing",
+			recursive: true,
+			expected:  0,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			templates, err := sim.driver.FindTemplates(c.folder, c.recursive)
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if templates == nil || len(templates) != c.expected {
+				t.Fatalf("unexpected result: expected %d templates, but returned %d", c.expected, len(templates))
+			}
+		})
+	}
+}
+
+func TestVCenterDriver_PreCleanVMs(t *testing.T) {
+	model := simulator.VPX()
+	model.Machine = 3
+	sim, err := NewCustomVCenterSimulator(model)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	all, err := sim.driver.FindVMs("*")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	matching, err := sim.driver.FindVMs("DC0_H0_VM*")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(matching) < 2 || len(matching) == len(all) {
+		t.Fatalf("unexpected result: expected several, but not all, virtual machines to match, but %d of %d matched", len(matching), len(all))
+	}
+
+	// Without force, the matching virtual machines are not removed.
+	if err := sim.driver.PreCleanVMs(packersdk.TestUi(t), "DC0_H0_VM*", false, "", "", ""); err == nil {
+		t.Fatalf("unexpected result: expected an error without force")
+	}
+	vms, err := sim.driver.FindVMs("*")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(vms) != len(all) {
+		t.Fatalf("unexpected result: expected %d virtual machines, but returned %d", len(all), len(vms))
+	}
+
+	if err := sim.driver.PreCleanVMs(packersdk.TestUi(t), "DC0_H0_VM*", true, "", "", ""); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	vms, err = sim.driver.FindVMs("*")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(vms) != len(all)-len(matching) {
+		t.Fatalf("unexpected result: expected %d virtual machines, but returned %d", len(all)-len(matching), len(vms))
+	}
+	for _, vm := range vms {
+		if strings.HasPrefix(vm.(*VirtualMachineDriver).vm.Name(), "DC0_H0_VM") {
+			t.Fatalf("unexpected result: expected '%s' to be removed", vm.(*VirtualMachineDriver).vm.Name())
+		}
+	}
+
+	// No matches is not an error.
+	if err := sim.driver.PreCleanVMs(packersdk.TestUi(t), "DC0_H0_VM*", false, "", "", ""); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	// A catch-all pattern is refused before anything is removed.
+	if err := sim.driver.PreCleanVMs(packersdk.TestUi(t), "*", true, "", "", ""); err == nil {
+		t.Fatalf("unexpected result: expected an error for a catch-all pattern")
+	}
+	remaining, err := sim.driver.FindVMs("*")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if len(remaining) != len(vms) {
+		t.Fatalf("unexpected result: expected %d virtual machines, but returned %d", len(vms), len(remaining))
+	}
+}
+
+func TestGlobHasNamePrefix(t *testing.T) {
+	tc := []struct {
+		glob     string
+		expected bool
+	}{
+		{glob: "packer-*", expected: true},
+		{glob: "packer-build", expected: true},
+		{glob: "builds/packer-?", expected: true},
+		{glob: "/DC0/vm/builds/packer-*", expected: true},
+		{glob: "*/packer-*", expected: true},
+		{glob: ""},
+		{glob: "*"},
+		{glob: "?"},
+		{glob: "[a-z]*"},
+		{glob: "*-build"},
+		{glob: "builds/*"},
+		{glob: "/DC0/vm/*"},
+		{glob: "builds/..."},
+		{glob: ".../packer-*"},
+		{glob: "/"},
+	}
+
+	for _, c := range tc {
+		if actual := globHasNamePrefix(c.glob); actual != c.expected {
+			t.Fatalf("unexpected result: expected %t for '%s', but returned %t", c.expected, c.glob, actual)
+		}
+	}
+}
+
+func TestVCenterDriver_GetInventoryPath(t *testing.T) {
+	sim, err := NewVCenterSimulator()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	_, machine := sim.ChooseSimulatorPreCreatedVM()
+	source := object.NewVirtualMachine(sim.driver.vimClient, machine.Reference())
+
+	// Clone identically named virtual machines into different folders.
+	var uuids []string
+	for _, name := range []string{"blue", "green"} {
+		folder, err := sim.driver.FindFolder(name)
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		task, err := source.Clone(sim.driver.ctx, folder.folder, "app", types.VirtualMachineCloneSpec{})
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		info, err := task.WaitForResult(sim.driver.ctx)
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		clone := sim.driver.NewVM(types.NewReference(info.Result.(types.ManagedObjectReference)))
+		config, err := clone.Info("config.uuid")
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		uuids = append(uuids, config.Config.Uuid)
+	}
+
+	for i, expected := range []string{"/DC0/vm/blue/app", "/DC0/vm/green/app"} {
+		vm, err := sim.driver.FindVMByUUID(uuids[i], false)
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		path, err := sim.driver.GetInventoryPath(vm)
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if path != expected {
+			t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, path)
+		}
+	}
+
+	// The path of a virtual machine located by path is returned unchanged.
+	vm, err := sim.driver.FindVM("blue/app")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	path, err := sim.driver.GetInventoryPath(vm)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if path != "/DC0/vm/blue/app" {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", "/DC0/vm/blue/app", path)
+	}
+
+	if _, err := sim.driver.GetInventoryPath(&VirtualMachineMock{}); err == nil {
+		t.Fatalf("unexpected result: expected an error for an unsupported virtual machine type")
+	}
+}
+
+func TestVCenterDriver_ValidatePlacement(t *testing.T) {
+	model := simulator.VPX()
+	model.Pool = 1
+	sim, err := NewCustomVCenterSimulator(model)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer sim.Close()
+
+	if _, err := sim.driver.FindFolder("templates"); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	tc := []struct {
+		name         string
+		cluster      string
+		host         string
+		resourcePool string
+		folder       string
+		missing      []string
+	}{
+		{
+			name: "empty placement",
+		},
+		{
+			name:         "cluster placement",
+			cluster:      "DC0_C0",
+			resourcePool: "DC0_C0_RP1",
+			folder:       "templates",
+		},
+		{
+			name:   "host placement",
+			host:   "DC0_H0",
+			folder: "/DC0/vm/templates",
+		},
+		{
+			name:    "missing cluster",
+			cluster: "missing",
+			missing: []string{"cluster 'missing' not found"},
+		},
+		{
+			name:         "missing pool within cluster",
+			cluster:      "DC0_C0",
+			resourcePool: "missing",
+			missing:      []string{"resource pool 'missing' not found in 'DC0_C0'"},
+		},
+		{
+			name:   "missing folders are created",
+			folder: "templates/missing/nested",
+		},
+		{
+			name:         "missing host, pool, and folder parent",
+			host:         "missing-host",
+			resourcePool: "missing-pool",
+			folder:       "DC0_H0_VM0/templates",
+			missing:      []string{"host 'missing-host' not found", "folder 'DC0_H0_VM0/templates' cannot be created"},
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			err := sim.driver.ValidatePlacement(c.cluster, c.host, c.resourcePool, c.folder)
+			if len(c.missing) == 0 {
+				if err != nil {
+					t.Fatalf("unexpected error: '%s'", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("unexpected result: expected an error")
+			}
+			errs, ok := err.(*packersdk.MultiError)
+			if !ok {
+				t.Fatalf("unexpected result: expected a multi error, but returned '%T'", err)
+			}
+			if len(errs.Errors) != len(c.missing) {
+				t.Fatalf("unexpected result: expected %d errors, but returned %d: '%s'", len(c.missing), len(errs.Errors), err)
+			}
+			for i, expected := range c.missing {
+				if !strings.HasPrefix(errs.Errors[i].Error(), expected) {
+					t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, errs.Errors[i])
+				}
+			}
+		})
+	}
+
+	// A missing resource pool falls back to the default resource pool, as in
+	// CreateVM, if the datacenter has a single one.
+	model = simulator.VPX()
+	model.Host = 0
+	single, err := NewCustomVCenterSimulator(model)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer single.Close()
+
+	if err := single.driver.ValidatePlacement("DC0_C0", "", "missing", ""); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+}
@@ -180,7 +180,15 @@ func (s *StepCreateVM) Run(_ context.Context, state multistep.StateBag) multiste
 	d := state.Get("driver").(driver.Driver)
 	vmPath := path.Join(s.Location.Folder, s.Location.VMName)
 
-	err := d.PreCleanVM(ui, vmPath, s.Force, s.Location.Cluster, s.Location.Host, s.Location.ResourcePool)
+	// Fail before an existing virtual machine is removed if the virtual
+	// machine cannot be placed.
+	err := d.ValidatePlacement(s.Location.Cluster, s.Location.Host, s.Location.ResourcePool, s.Location.Folder)
+	if err != nil {
+		state.Put("error", fmt.Errorf("error validating virtual machine placement: %s", err))
+		return multistep.ActionHalt
+	}
+
+	err = d.PreCleanVM(ui, vmPath, s.Force, s.Location.Cluster, s.Location.Host, s.Location.ResourcePool)
 	if err != nil {
 		state.Put("error", err)
 		return multistep.ActionHalt
@@ -213,6 +213,10 @@ func TestStepCreateVM_Run(t *testing.T) {
 		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionContinue, action)
 	}
 
+	if !driverMock.ValidatePlacementCalled {
+		t.Fatalf("unexpected result: expected '%s' to be called", "ValidatePlacement")
+	}
+
 	// Pre clean VM
 	if !driverMock.PreCleanVMCalled {
 		t.Fatalf("unexpected result: expected '%s' to be called", "PreCleanVM")
@@ -244,8 +248,26 @@ func TestStepCreateVM_RunHalt(t *testing.T) {
 	state := basicStateBag()
 	step := basicStepCreateVM()
 
-	// PreCleanVM fails
+	// ValidatePlacement fails
 	driverMock := driver.NewDriverMock()
+	driverMock.ValidatePlacementShouldFail = true
+	state.Put("driver", driverMock)
+	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
+		t.Fatalf("unexpected action: expected '%#v', but returned '%#v'", multistep.ActionHalt, action)
+	}
+	if !driverMock.ValidatePlacementCalled {
+		t.Fatalf("unexpected result: expected '%s' to be called", "ValidatePlacement")
+	}
+	if driverMock.PreCleanVMCalled || driverMock.CreateVMCalled {
+		t.Fatalf("unexpected result: expected '%s' and '%s' not to be called", "PreCleanVM", "CreateVM")
+	}
+	expected := "error validating virtual machine placement: resource pool 'test-resource-pool' not found"
+	if err := state.Get("error").(error); err.Error() != expected {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", expected, err)
+	}
+
+	// PreCleanVM fails
+	driverMock = driver.NewDriverMock()
 	driverMock.PreCleanShouldFail = true
 	state.Put("driver", driverMock)
 	if action := step.Run(context.TODO(), state); action != multistep.ActionHalt {
@@ -0,0 +1,202 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+//go:generate packer-sdc struct-markdown
+//go:generate packer-sdc mapstructure-to-hcl2 -type Config,DatasourceOutput
+
+package vsphere_template
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/hcl/v2/hcldec"
+	"github.com/hashicorp/packer-plugin-sdk/common"
+	"github.com/hashicorp/packer-plugin-sdk/hcl2helper"
+	packersdk "github.com/hashicorp/packer-plugin-sdk/packer"
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	vsphere "github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// DefaultVersionRegex matches a semantic version at the end of the template
+// name, with an optional `v` prefix, such as `ubuntu-22.04-v1.2.3`.
+const DefaultVersionRegex = `v?(\d+\.\d+\.\d+)$`
+
+type Config struct {
+	common.PackerConfig   `mapstructure:",squash"`
+	vsphere.ConnectConfig `mapstructure:",squash"`
+	// The path of the virtual machine folder to search for templates. For
+	// example, `templates/linux`. A relative path is resolved from the virtual
+	// machine folder of the datacenter. Defaults to the virtual machine folder
+	// of the datacenter.
+	Folder string `mapstructure:"folder"`
+	// Search the subfolders of `folder` for templates. Defaults to `true`.
+	Recursive config.Trilean `mapstructure:"recursive"`
+	// A regular expression that the template name must match. For example,
+	// `^ubuntu-22\.04-`. Defaults to all templates.
+	NameRegex string `mapstructure:"name_regex"`
+	// A regular expression that extracts the semantic version from the
+	// template name. The first capture group is used as the version, or the
+	// whole match if the expression has no capture group. Templates without a
+	// valid semantic version are ignored. Defaults to `v?(\d+\.\d+\.\d+)$`,
+	// which matches `1.2.3` in `ubuntu-22.04-v1.2.3`.
+	//
+	// -> **Note:** Set this option to include pre-release versions. For
+	// example, `v(\d+\.\d+\.\d+(?:-[0-9A-Za-z.]+)?)$` matches `1.2.3-rc.1` in
+	// `ubuntu-22.04-v1.2.3-rc.1`.
+	VersionRegex string `mapstructure:"version_regex"`
+
+	nameRegex    *regexp.Regexp
+	versionRegex *regexp.Regexp
+}
+
+type DatasourceOutput struct {
+	// The name of the template with the highest version.
+	Name string `mapstructure:"name"`
+	// The version extracted from the name of the template.
+	Version string `mapstructure:"version"`
+	// The full inventory path of the template, such as
+	// `/datacenter/vm/templates/ubuntu-22.04-v1.2.3`.
+	InventoryPath string `mapstructure:"inventory_path"`
+}
+
+type Datasource struct {
+	config Config
+}
+
+func (d *Datasource) ConfigSpec() hcldec.ObjectSpec {
+	return d.config.FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Configure(raws ...interface{}) error {
+	err := config.Decode(&d.config, nil, raws...)
+	if err != nil {
+		return err
+	}
+
+	errs := new(packersdk.MultiError)
+	errs = packersdk.MultiErrorAppend(errs, d.config.ConnectConfig.Prepare()...)
+
+	if d.config.NameRegex != "" {
+		d.config.nameRegex, err = regexp.Compile(d.config.NameRegex)
+		if err != nil {
+			errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'name_regex' is invalid: %s", err))
+		}
+	}
+	if d.config.VersionRegex == "" {
+		d.config.VersionRegex = DefaultVersionRegex
+	}
+	d.config.versionRegex, err = regexp.Compile(d.config.VersionRegex)
+	if err != nil {
+		errs = packersdk.MultiErrorAppend(errs, fmt.Errorf("'version_regex' is invalid: %s", err))
+	}
+
+	if len(errs.Errors) > 0 {
+		return errs
+	}
+	return nil
+}
+
+func (d *Datasource) OutputSpec() hcldec.ObjectSpec {
+	return (&DatasourceOutput{}).FlatMapstructure().HCL2Spec()
+}
+
+func (d *Datasource) Execute() (cty.Value, error) {
+	dr, err := d.config.ConnectConfig.NewDriver(context.Background())
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	defer func() {
+		errorRestClient, errorSoapClient := dr.Cleanup()
+		if errorRestClient != nil {
+			log.Printf("[WARN] Failed to close REST client session. The session may already be closed: %s", errorRestClient.Error())
+		}
+		if errorSoapClient != nil {
+			log.Printf("[WARN] Failed to close SOAP client session. The session may already be closed: %s", errorSoapClient.Error())
+		}
+	}()
+
+	if _, err := dr.FindFolderByPath(d.config.Folder); err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("error finding folder '%s': %s", d.config.Folder, err)
+	}
+	templates, err := dr.FindTemplates(d.config.Folder, !d.config.Recursive.False())
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), fmt.Errorf("error listing templates: %s", err)
+	}
+
+	paths := make([]string, 0, len(templates))
+	for _, template := range templates {
+		p, err := dr.GetInventoryPath(template)
+		if err != nil {
+			return cty.NullVal(cty.EmptyObject), err
+		}
+		paths = append(paths, p)
+	}
+
+	output, err := latestTemplate(paths, d.config.nameRegex, d.config.versionRegex)
+	if err != nil {
+		return cty.NullVal(cty.EmptyObject), err
+	}
+	return hcl2helper.HCL2ValueFromConfig(output, d.OutputSpec()), nil
+}
+
+// latestTemplate returns the template with the highest version of the
+// templates at the inventory paths. Templates that do not match nameRegex, if
+// set, or that have no valid semantic version are ignored. Returns an error if
+// no template has a version or if several templates have the highest version.
+func latestTemplate(paths []string, nameRegex, versionRegex *regexp.Regexp) (DatasourceOutput, error) {
+	var latest DatasourceOutput
+	var latestVersion *version.Version
+	var duplicates []string
+
+	for _, p := range paths {
+		// The inventory path escapes the special characters of the name, such
+		// as "/" and "%".
+		name, err := url.PathUnescape(path.Base(p))
+		if err != nil {
+			name = path.Base(p)
+		}
+		if nameRegex != nil && !nameRegex.MatchString(name) {
+			continue
+		}
+
+		match := versionRegex.FindStringSubmatch(name)
+		if match == nil {
+			continue
+		}
+		raw := match[0]
+		if len(match) > 1 {
+			raw = match[1]
+		}
+		v, err := version.NewSemver(raw)
+		if err != nil {
+			log.Printf("[DEBUG] Ignoring template '%s': '%s' is not a semantic version", name, raw)
+			continue
+		}
+
+		switch {
+		case latestVersion == nil || v.GreaterThan(latestVersion):
+			latest = DatasourceOutput{Name: name, Version: raw, InventoryPath: p}
+			latestVersion = v
+			duplicates = nil
+		case v.Equal(latestVersion):
+			duplicates = append(duplicates, p)
+		}
+	}
+
+	if latestVersion == nil {
+		return DatasourceOutput{}, fmt.Errorf("no template with a version matching '%s' found", versionRegex)
+	}
+	if len(duplicates) > 0 {
+		return DatasourceOutput{}, fmt.Errorf("several templates have the version '%s': %s; set 'name_regex', 'folder' or 'recursive' to select one",
+			latest.Version, strings.Join(append([]string{latest.InventoryPath}, duplicates...), ", "))
+	}
+	return latest, nil
+}
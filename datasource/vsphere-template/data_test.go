@@ -0,0 +1,325 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package vsphere_template
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/packer-plugin-sdk/template/config"
+	"github.com/vmware/govmomi"
+	"github.com/vmware/govmomi/find"
+	"github.com/vmware/govmomi/simulator"
+	"github.com/vmware/govmomi/vim25/types"
+)
+
+func TestDatasource_Configure(t *testing.T) {
+	tc := []struct {
+		name     string
+		config   map[string]interface{}
+		expected string
+		fail     bool
+	}{
+		{
+			name:     "Should use the default version regex",
+			config:   map[string]interface{}{},
+			expected: DefaultVersionRegex,
+		},
+		{
+			name: "Should use the explicit version regex",
+			config: map[string]interface{}{
+				"version_regex": `-(\d+\.\d+\.\d+)-`,
+			},
+			expected: `-(\d+\.\d+\.\d+)-`,
+		},
+		{
+			name: "Should fail for invalid name regex",
+			config: map[string]interface{}{
+				"name_regex": "ubuntu-(",
+			},
+			fail: true,
+		},
+		{
+			name: "Should fail for invalid version regex",
+			config: map[string]interface{}{
+				"version_regex": "v(",
+			},
+			fail: true,
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			raw := map[string]interface{}{
+				"vcenter_server": "vcenter.example.com",
+				"username":       "administrator@vsphere.local",
+				"password":       "password",
+			}
+			for k, v := range c.config {
+				raw[k] = v
+			}
+
+			var d Datasource
+			err := d.Configure(raw)
+			if c.fail {
+				if err == nil {
+					t.Fatalf("unexpected success: expected failure")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if d.config.Recursive.False() {
+				t.Fatalf("unexpected result: expected 'recursive' to be unset, but returned false")
+			}
+			if d.config.VersionRegex != c.expected {
+				t.Fatalf("unexpected result: expected '%s', but returned '%s'", c.expected, d.config.VersionRegex)
+			}
+		})
+	}
+}
+
+func TestLatestTemplate(t *testing.T) {
+	tc := []struct {
+		name         string
+		paths        []string
+		nameRegex    string
+		versionRegex string
+		expected     DatasourceOutput
+		fail         string
+	}{
+		{
+			name: "Should return the highest version",
+			paths: []string{
+				"/DC0/vm/ubuntu-22.04-v1.2.3",
+				"/DC0/vm/ubuntu-22.04-v1.10.0",
+				"/DC0/vm/ubuntu-22.04-v1.9.9",
+			},
+			expected: DatasourceOutput{
+				Name:          "ubuntu-22.04-v1.10.0",
+				Version:       "1.10.0",
+				InventoryPath: "/DC0/vm/ubuntu-22.04-v1.10.0",
+			},
+		},
+		{
+			name: "Should ignore templates without a version",
+			paths: []string{
+				"/DC0/vm/templates/ubuntu-22.04-v1.2.3",
+				"/DC0/vm/templates/ubuntu-22.04",
+				"/DC0/vm/templates/ubuntu-22.04-latest",
+			},
+			expected: DatasourceOutput{
+				Name:          "ubuntu-22.04-v1.2.3",
+				Version:       "1.2.3",
+				InventoryPath: "/DC0/vm/templates/ubuntu-22.04-v1.2.3",
+			},
+		},
+		{
+			name: "Should filter templates by name",
+			paths: []string{
+				"/DC0/vm/ubuntu-22.04-v1.2.3",
+				"/DC0/vm/debian-12-v2.0.0",
+			},
+			nameRegex: "^ubuntu-",
+			expected: DatasourceOutput{
+				Name:          "ubuntu-22.04-v1.2.3",
+				Version:       "1.2.3",
+				InventoryPath: "/DC0/vm/ubuntu-22.04-v1.2.3",
+			},
+		},
+		{
+			name: "Should use the whole match without a capture group",
+			paths: []string{
+				"/DC0/vm/ubuntu-1.2.3-22.04",
+				"/DC0/vm/ubuntu-1.3.0-22.04",
+			},
+			versionRegex: `\d+\.\d+\.\d+`,
+			expected: DatasourceOutput{
+				Name:          "ubuntu-1.3.0-22.04",
+				Version:       "1.3.0",
+				InventoryPath: "/DC0/vm/ubuntu-1.3.0-22.04",
+			},
+		},
+		{
+			name: "Should order a pre-release before its release",
+			paths: []string{
+				"/DC0/vm/ubuntu-v1.2.3-rc.1",
+				"/DC0/vm/ubuntu-v1.2.3",
+			},
+			versionRegex: `v(\d+\.\d+\.\d+(?:-[0-9A-Za-z.]+)?)$`,
+			expected: DatasourceOutput{
+				Name:          "ubuntu-v1.2.3",
+				Version:       "1.2.3",
+				InventoryPath: "/DC0/vm/ubuntu-v1.2.3",
+			},
+		},
+		{
+			name: "Should unescape the name",
+			paths: []string{
+				"/DC0/vm/ubuntu%2f22.04-v1.2.3",
+			},
+			expected: DatasourceOutput{
+				Name:          "ubuntu/22.04-v1.2.3",
+				Version:       "1.2.3",
+				InventoryPath: "/DC0/vm/ubuntu%2f22.04-v1.2.3",
+			},
+		},
+		{
+			name: "Should fail for no versioned templates",
+			paths: []string{
+				"/DC0/vm/ubuntu-22.04",
+			},
+			fail: "no template with a version matching",
+		},
+		{
+			name: "Should fail for several templates with the highest version",
+			paths: []string{
+				"/DC0/vm/ubuntu-22.04-v1.2.3",
+				"/DC0/vm/debian-12-v1.2.3",
+				"/DC0/vm/debian-12-v1.0.0",
+			},
+			fail: "several templates have the version '1.2.3'",
+		},
+	}
+
+	for _, c := range tc {
+		t.Run(c.name, func(t *testing.T) {
+			var nameRegex *regexp.Regexp
+			if c.nameRegex != "" {
+				nameRegex = regexp.MustCompile(c.nameRegex)
+			}
+			versionRegex := regexp.MustCompile(DefaultVersionRegex)
+			if c.versionRegex != "" {
+				versionRegex = regexp.MustCompile(c.versionRegex)
+			}
+
+			output, err := latestTemplate(c.paths, nameRegex, versionRegex)
+			if c.fail != "" {
+				if err == nil || !strings.Contains(err.Error(), c.fail) {
+					t.Fatalf("unexpected result: expected '%s', but returned '%v'", c.fail, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: '%s'", err)
+			}
+			if output != c.expected {
+				t.Fatalf("unexpected result: expected '%+v', but returned '%+v'", c.expected, output)
+			}
+		})
+	}
+}
+
+func TestDatasource_Execute(t *testing.T) {
+	model := simulator.VPX()
+	model.Machine = 3
+	if err := model.Create(); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	defer model.Remove()
+	model.Service.RegisterEndpoints = true
+	model.Service.TLS = new(tls.Config)
+	model.Service.ServeMux = http.NewServeMux()
+	server := model.Service.NewServer()
+	defer server.Close()
+
+	// Mark two virtual machines as versioned templates and leave a virtual
+	// machine with a higher version in the name.
+	ctx := context.Background()
+	client, err := govmomi.NewClient(ctx, server.URL, true)
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	vms, err := find.NewFinder(client.Client, true).VirtualMachineList(ctx, "/DC0/vm/*")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	names := []string{"ubuntu-22.04-v1.2.3", "ubuntu-22.04-v1.10.0", "ubuntu-22.04-v2.0.0"}
+	for i, vm := range vms[:len(names)] {
+		task, err := vm.Rename(ctx, names[i])
+		if err == nil {
+			err = task.Wait(ctx)
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+		if i == len(names)-1 {
+			continue
+		}
+		task, err = vm.PowerOff(ctx)
+		if err == nil {
+			err = task.Wait(ctx)
+		}
+		if err == nil {
+			err = vm.MarkAsTemplate(ctx)
+		}
+		if err != nil {
+			t.Fatalf("unexpected error: '%s'", err)
+		}
+	}
+
+	password, _ := server.URL.User.Password()
+	var d Datasource
+	if err := d.Configure(map[string]interface{}{
+		"vcenter_server":      server.URL.Host,
+		"username":            server.URL.User.Username(),
+		"password":            password,
+		"insecure_connection": true,
+	}); err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	value, err := d.Execute()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	expected := map[string]string{
+		"name":           "ubuntu-22.04-v1.10.0",
+		"version":        "1.10.0",
+		"inventory_path": "/DC0/vm/ubuntu-22.04-v1.10.0",
+	}
+	for key, want := range expected {
+		if got := value.GetAttr(key).AsString(); got != want {
+			t.Fatalf("unexpected result: expected '%s', but returned '%s'", want, got)
+		}
+	}
+
+	// Without the subfolders, the highest version is the template that remains
+	// in the datacenter folder.
+	finder := find.NewFinder(client.Client, true)
+	root, err := finder.Folder(ctx, "/DC0/vm")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	archive, err := root.CreateFolder(ctx, "archive")
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	task, err := archive.MoveInto(ctx, []types.ManagedObjectReference{vms[1].Reference()})
+	if err == nil {
+		err = task.Wait(ctx)
+	}
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+
+	d.config.Recursive = config.TriFalse
+	value, err = d.Execute()
+	if err != nil {
+		t.Fatalf("unexpected error: '%s'", err)
+	}
+	if got := value.GetAttr("name").AsString(); got != names[0] {
+		t.Fatalf("unexpected result: expected '%s', but returned '%s'", names[0], got)
+	}
+
+	d.config.Folder = "missing"
+	if _, err := d.Execute(); err == nil || !strings.Contains(err.Error(), "error finding folder 'missing'") {
+		t.Fatalf("unexpected result: expected folder not found error, but returned '%v'", err)
+	}
+}
@@ -4,6 +4,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/hashicorp/packer-plugin-vsphere/builder/vsphere/common/utils"
@@ -16,7 +17,7 @@ func main() {
 	password := utils.GetenvOrDefault(utils.EnvVspherePassword, utils.DefaultVspherePassword)
 	host := utils.GetenvOrDefault(utils.EnvVsphereHost, utils.DefaultVsphereHost)
 
-	d, err := driver.NewDriver(&driver.ConnectConfig{
+	d, err := driver.NewDriver(context.Background(), &driver.ConnectConfig{
 		VCenterServer:      vcenter,
 		Username:           username,
 		Password:           password,